@@ -0,0 +1,53 @@
+package cidr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func benchmarkLookup(b *testing.B, numPrefixes int, v6 bool) {
+	table := NilTable[int]()
+	for i := 0; i < numPrefixes; i++ {
+		table = table.Insert(randomPrefix(v6), i)
+	}
+	addrs := make([]netip.Addr, 1000)
+	for i := range addrs {
+		addrs[i] = randomPrefix(v6).Addr()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Lookup(addrs[i%len(addrs)])
+	}
+}
+
+func BenchmarkLookup_IPv4_10kPrefixes(b *testing.B) {
+	benchmarkLookup(b, 10*1000, false)
+}
+
+func BenchmarkLookup_IPv6_10kPrefixes(b *testing.B) {
+	benchmarkLookup(b, 10*1000, true)
+}
+
+func benchmarkInsert(b *testing.B, numPrefixes int) {
+	prefixes := make([]netip.Prefix, numPrefixes)
+	for i := range prefixes {
+		prefixes[i] = randomPrefix(false)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table := NilTable[int]()
+		for j, p := range prefixes {
+			table = table.Insert(p, j)
+		}
+	}
+}
+
+func BenchmarkInsert_100Prefixes(b *testing.B) {
+	benchmarkInsert(b, 100)
+}
+
+func BenchmarkInsert_10kPrefixes(b *testing.B) {
+	benchmarkInsert(b, 10*1000)
+}
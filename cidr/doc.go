@@ -0,0 +1,34 @@
+/*
+Package cidr implements an immutable IP routing table keyed by CIDR prefixes.
+It is built on a critbit trie, where each prefix is keyed by its address family
+followed by one byte for each of its bits, so prefixes can have any length such as
+/13 or /27.  A shorter prefix's key is then a prefix of the keys of the longer
+prefixes inside it, so the longest match for an address is the trie's longest
+prefix of the address's key.  IPv4-mapped IPv6 addresses and prefixes are treated
+as the IPv4 ones they map.
+
+Like the critbit trie, every change returns a new table which shares structure
+with the old one, so a table can be replaced atomically while readers keep using
+the version they loaded.
+
+Examples:
+
+building a table -
+
+	table := cidr.NilTable[string]()
+	table = table.Insert(netip.MustParsePrefix("10.0.0.0/8"), "internal")
+	table = table.Insert(netip.MustParsePrefix("10.1.0.0/16"), "lab")
+
+finding the longest matching prefix for an address -
+
+	prefix, route, ok := table.Lookup(netip.MustParseAddr("10.1.2.3"))
+	//prefix == 10.1.0.0/16, route == "lab", ok == true
+
+iterating over the prefixes in order -
+
+	table.Visit(func(prefix netip.Prefix, route string) bool {
+		fmt.Println(prefix, route)
+		return true
+	})
+*/
+package cidr
@@ -0,0 +1,117 @@
+package cidr
+
+import (
+	"iter"
+	"net/netip"
+
+	"github.com/gburgett/immutable/critbit"
+)
+
+// Table is an immutable routing table mapping CIDR prefixes to values.
+type Table[V any] struct {
+	// the routes keyed by their prefix bits, see prefixKey.
+	routes critbit.TypedTrie[route[V]]
+}
+
+// A route is stored alongside its prefix so that the prefix doesn't have to be decoded from
+// the key.
+type route[V any] struct {
+	prefix netip.Prefix
+	value  V
+}
+
+// Gets an empty table.
+func NilTable[V any]() *Table[V] {
+	return &Table[V]{}
+}
+
+// Gets the number of prefixes in the table.
+func (t *Table[V]) Len() int {
+	return int(t.routes.Len())
+}
+
+// Returns a new table with the prefix set to the given value.  The prefix is masked, so
+// 10.1.2.3/8 is the same as 10.0.0.0/8, and an IPv4-mapped IPv6 prefix of at least 96 bits is
+// stored as the IPv4 prefix it maps, so ::ffff:10.0.0.0/104 is the same as 10.0.0.0/8.
+// Panics if the prefix is not valid.
+func (t *Table[V]) Insert(prefix netip.Prefix, value V) *Table[V] {
+	p := normalize(prefix)
+	routes, _ := t.routes.Set(prefixKey(p.Addr(), p.Bits()), route[V]{prefix: p, value: value})
+	return &Table[V]{routes: *routes}
+}
+
+// Gets the value for exactly the given prefix.  Returns the value and a boolean which is true
+// if the prefix existed.
+func (t *Table[V]) Get(prefix netip.Prefix) (V, bool) {
+	p := normalize(prefix)
+	r, ok := t.routes.Get(prefixKey(p.Addr(), p.Bits()))
+	return r.value, ok
+}
+
+// Finds the longest prefix in the table which contains the address, and its value.
+// An IPv4-mapped IPv6 address is looked up as the IPv4 address it maps.
+// Returns false if no prefix contains it.
+func (t *Table[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	if !addr.IsValid() {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	addr = addr.Unmap()
+	// the prefixes containing the address are exactly the keys which prefix its key.
+	_, r, ok := t.routes.LongestPrefix(prefixKey(addr, addr.BitLen()))
+	return r.prefix, r.value, ok
+}
+
+// Returns a new table without the given prefix, along with whether the prefix existed.
+// If it didn't exist, this table is returned.
+func (t *Table[V]) Delete(prefix netip.Prefix) (*Table[V], bool) {
+	p := normalize(prefix)
+	routes, _ := t.routes.Delete(prefixKey(p.Addr(), p.Bits()))
+	if routes == &t.routes {
+		return t, false
+	}
+	return &Table[V]{routes: *routes}, true
+}
+
+// Applies the visitor function to each prefix in order, with IPv4 prefixes first.  A prefix
+// comes before the longer prefixes inside it.  The visitor's boolean return value indicates
+// whether to continue.
+func (t *Table[V]) Visit(visitor func(netip.Prefix, V) bool) {
+	t.routes.VisitAscend(nil, func(_ []byte, r route[V]) bool {
+		return visitor(r.prefix, r.value)
+	})
+}
+
+// Gets an iterator over the prefixes in the same order as Visit.
+func (t *Table[V]) All() iter.Seq2[netip.Prefix, V] {
+	return t.Visit
+}
+
+// masks the prefix, unmapping it if it is an IPv4-mapped prefix.
+func normalize(prefix netip.Prefix) netip.Prefix {
+	if !prefix.IsValid() {
+		panic("invalid prefix")
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked()
+}
+
+// Gets the trie key for the first @bits bits of the address.  It starts with the address
+// family, which puts IPv4 first, followed by one byte for each bit.  So a prefix's key is a
+// prefix of the keys of the longer prefixes and addresses inside it, and sorts before them.
+func prefixKey(addr netip.Addr, bits int) []byte {
+	family := byte(6)
+	if addr.Is4() {
+		family = 4
+	}
+	key := make([]byte, 1, 1+bits)
+	key[0] = family
+	for i, b := range addr.AsSlice()[:(bits+7)/8] {
+		for j := 0; j < 8 && i*8+j < bits; j++ {
+			key = append(key, b>>(7-j)&1)
+		}
+	}
+	return key
+}
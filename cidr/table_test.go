@@ -0,0 +1,220 @@
+package cidr
+
+import (
+	"math/rand"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tableToSlice(t *Table[int]) []string {
+	ret := make([]string, 0, t.Len())
+	for p := range t.All() {
+		ret = append(ret, p.String())
+	}
+	return ret
+}
+
+func randomPrefix(v6 bool) netip.Prefix {
+	var addr netip.Addr
+	if v6 {
+		var b [16]byte
+		rand.Read(b[:])
+		// keep the prefixes close together so that they nest
+		b[0], b[1] = 0x20, 0x01
+		addr = netip.AddrFrom16(b)
+	} else {
+		var b [4]byte
+		rand.Read(b[:])
+		b[0] = 10
+		addr = netip.AddrFrom4(b)
+	}
+	return netip.PrefixFrom(addr, rand.Intn(addr.BitLen()+1)).Masked()
+}
+
+func TestNilTable(t *testing.T) {
+	table := NilTable[int]()
+
+	//act
+	_, _, ok := table.Lookup(netip.MustParseAddr("10.0.0.1"))
+	_, getOk := table.Get(netip.MustParsePrefix("10.0.0.0/8"))
+	deleted, deleteOk := table.Delete(netip.MustParsePrefix("10.0.0.0/8"))
+
+	//assert
+	assert.Equal(t, 0, table.Len())
+	assert.False(t, ok)
+	assert.False(t, getOk)
+	assert.False(t, deleteOk)
+	assert.True(t, table == deleted, "deleting from an empty table")
+	assert.Empty(t, tableToSlice(table))
+}
+
+func TestInsert_Lookup_LongestMatch(t *testing.T) {
+	table := NilTable[int]()
+	table = table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 8)
+	table = table.Insert(netip.MustParsePrefix("10.1.0.0/16"), 16)
+	table = table.Insert(netip.MustParsePrefix("10.1.2.0/27"), 27)
+	table = table.Insert(netip.MustParsePrefix("0.0.0.0/0"), 0)
+
+	//act & assert
+	cases := map[string]string{
+		"10.1.2.3":    "10.1.2.0/27",
+		"10.1.2.40":   "10.1.0.0/16",
+		"10.200.0.1":  "10.0.0.0/8",
+		"192.168.0.1": "0.0.0.0/0",
+	}
+	for addr, expected := range cases {
+		prefix, _, ok := table.Lookup(netip.MustParseAddr(addr))
+		assert.True(t, ok, addr)
+		assert.Equal(t, expected, prefix.String(), addr)
+	}
+	_, _, ok := table.Lookup(netip.MustParseAddr("2001:db8::1"))
+	assert.False(t, ok, "IPv6 addresses don't match IPv4 prefixes")
+}
+
+func TestInsert_MasksPrefix(t *testing.T) {
+
+	//act
+	table := NilTable[int]().Insert(netip.MustParsePrefix("10.1.2.3/13"), 13)
+
+	//assert
+	assert.Equal(t, []string{"10.0.0.0/13"}, tableToSlice(table))
+	val, ok := table.Get(netip.MustParsePrefix("10.7.0.0/13"))
+	assert.True(t, ok)
+	assert.Equal(t, 13, val)
+}
+
+func TestInsert_Replaces(t *testing.T) {
+	table := NilTable[int]().Insert(netip.MustParsePrefix("10.0.0.0/8"), 1)
+
+	//act
+	replaced := table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 2)
+
+	//assert
+	assert.Equal(t, 1, replaced.Len())
+	val, _ := replaced.Get(netip.MustParsePrefix("10.0.0.0/8"))
+	assert.Equal(t, 2, val)
+	val, _ = table.Get(netip.MustParsePrefix("10.0.0.0/8"))
+	assert.Equal(t, 1, val, "original should remain immutable")
+}
+
+func TestInsert_InvalidPrefix_Panics(t *testing.T) {
+	assert.Panics(t, func() { NilTable[int]().Insert(netip.Prefix{}, 1) })
+}
+
+func TestDelete_KeepsOtherPrefixes(t *testing.T) {
+	table := NilTable[int]()
+	table = table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 8)
+	table = table.Insert(netip.MustParsePrefix("10.1.0.0/16"), 16)
+	table = table.Insert(netip.MustParsePrefix("10.2.0.0/16"), 16)
+
+	//act
+	deleted, ok := table.Delete(netip.MustParsePrefix("10.0.0.0/8"))
+	missing, missingOk := deleted.Delete(netip.MustParsePrefix("10.0.0.0/8"))
+
+	//assert
+	assert.True(t, ok)
+	assert.Equal(t, []string{"10.1.0.0/16", "10.2.0.0/16"}, tableToSlice(deleted))
+	_, _, lookupOk := deleted.Lookup(netip.MustParseAddr("10.3.0.1"))
+	assert.False(t, lookupOk)
+	assert.False(t, missingOk)
+	assert.True(t, deleted == missing, "deleting a missing prefix")
+	assert.Equal(t, 3, table.Len(), "original should remain immutable")
+}
+
+func TestIPv4Mapped_TreatedAsIPv4(t *testing.T) {
+	table := NilTable[int]()
+	table = table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 1)
+	table = table.Insert(netip.MustParsePrefix("::ffff:10.1.0.0/112"), 2)
+
+	//act
+	prefix, value, ok := table.Lookup(netip.MustParseAddr("::ffff:10.1.2.3"))
+	mapped, mappedOk := table.Get(netip.MustParsePrefix("::ffff:10.0.0.0/104"))
+	deleted, deleteOk := table.Delete(netip.MustParsePrefix("::ffff:10.1.0.0/112"))
+
+	//assert
+	assert.True(t, ok)
+	assert.Equal(t, "10.1.0.0/16", prefix.String())
+	assert.Equal(t, 2, value)
+	assert.True(t, mappedOk)
+	assert.Equal(t, 1, mapped)
+	assert.True(t, deleteOk)
+	assert.Equal(t, []string{"10.0.0.0/8"}, tableToSlice(deleted))
+	assert.Equal(t, []string{"10.0.0.0/8", "10.1.0.0/16"}, tableToSlice(table))
+}
+
+func TestVisit_OrdersIPv4First(t *testing.T) {
+	table := NilTable[int]()
+	for _, p := range []string{"2001:db8::/32", "10.1.0.0/16", "::/0", "10.0.0.0/8", "192.168.0.0/16", "0.0.0.0/0"} {
+		table = table.Insert(netip.MustParsePrefix(p), 0)
+	}
+
+	//act
+	prefixes := tableToSlice(table)
+
+	//assert
+	assert.Equal(t, []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "192.168.0.0/16", "::/0", "2001:db8::/32"}, prefixes)
+}
+
+func TestTable_Random_MatchesBruteForce(t *testing.T) {
+	for _, v6 := range []bool{false, true} {
+		table := NilTable[int]()
+		expected := make(map[netip.Prefix]int)
+		for i := 0; i < 2000; i++ {
+			p := randomPrefix(v6)
+			if rand.Intn(4) == 0 {
+				_, existed := expected[p]
+				var ok bool
+				table, ok = table.Delete(p)
+				require.Equal(t, existed, ok, "delete %s", p)
+				delete(expected, p)
+			} else {
+				table = table.Insert(p, i)
+				expected[p] = i
+			}
+			require.Equal(t, len(expected), table.Len())
+		}
+
+		for i := 0; i < 500; i++ {
+			addr := randomPrefix(v6).Addr()
+
+			var best netip.Prefix
+			found := false
+			for p := range expected {
+				if p.Contains(addr) && (!found || p.Bits() > best.Bits()) {
+					best, found = p, true
+				}
+			}
+
+			//act
+			prefix, val, ok := table.Lookup(addr)
+
+			//assert
+			require.Equal(t, found, ok, "lookup %s", addr)
+			if found {
+				assert.Equal(t, best, prefix, "lookup %s", addr)
+				assert.Equal(t, expected[best], val, "lookup %s", addr)
+			}
+		}
+
+		sorted := make([]netip.Prefix, 0, len(expected))
+		for p := range expected {
+			sorted = append(sorted, p)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if c := sorted[i].Addr().Compare(sorted[j].Addr()); c != 0 {
+				return c < 0
+			}
+			return sorted[i].Bits() < sorted[j].Bits()
+		})
+		visited := make([]netip.Prefix, 0, len(expected))
+		table.Visit(func(p netip.Prefix, val int) bool {
+			visited = append(visited, p)
+			return true
+		})
+		assert.Equal(t, sorted, visited)
+	}
+}
@@ -0,0 +1,150 @@
+package critbit
+
+import (
+	"bytes"
+	"reflect"
+)
+
+/*
+Alter reads and updates the value for @key in a single walk down the trie.  The @fn function gets
+the current value and whether the key exists, and returns the new value and whether the key
+should exist afterwards.  So it can insert, update or delete the key.  If nothing changed, either
+because the key is still missing or because @fn returned the same value, this trie is returned.
+ex:
+
+	//increment a counter
+	counts = counts.Alter([]byte("hits"), func(old interface{}, exists bool) (interface{}, bool) {
+		if !exists {
+			return 1, true
+		}
+		return old.(int) + 1, true
+	})
+*/
+func (t *TypedTrie[V]) Alter(key []byte, fn func(old V, exists bool) (V, bool)) *TypedTrie[V] {
+	return t.alter(key, func(old V, exists bool) (V, bool, bool) {
+		value, keep := fn(old, exists)
+		return value, keep, keep != exists || (keep && !sameValue(old, value))
+	})
+}
+
+// Alters the key in the same way as Alter, except @fn also says whether it made a change.
+func (t *TypedTrie[V]) alter(key []byte, fn func(old V, exists bool) (value V, keep bool, changed bool)) *TypedTrie[V] {
+	var zero V
+	if t.root == nil {
+		value, keep, changed := fn(zero, false)
+		if !keep || !changed {
+			return t
+		}
+		return t.withRoot(newLeaf(key, value))
+	}
+
+	// remember the path so that it can be copied on the way back up.
+	path := make([]*inner[V], 0, 32)
+	n := t.root
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		path = append(path, in)
+		n = in.children[findDirection(key, in.critbyte, in.critbit)]
+	}
+	l := n.(*leaf[V])
+
+	exists := bytes.Equal(key, l.key)
+	old := zero
+	if exists {
+		old = l.value
+	}
+	value, keep, changed := fn(old, exists)
+
+	switch {
+	case !changed || (!exists && !keep):
+		return t
+	case exists && keep:
+		return t.withRoot(rebuildPath(path, len(path), key, newLeaf(key, value), 0))
+	case exists:
+		if len(path) == 0 {
+			return t.withRoot(nil)
+		}
+		//the leaf's parent is replaced by the leaf's sibling
+		parent := path[len(path)-1]
+		sibling := parent.children[1-findDirection(key, parent.critbyte, parent.critbit)]
+		return t.withRoot(rebuildPath(path, len(path)-1, key, sibling, -1))
+	}
+
+	//insert a new node above the first node on the path whose critbit comes after the new one.
+	critbyte, critbit := findCritbit(key, l.key)
+	i := 0
+	for i < len(path) && !positionLess(critbyte, critbit, path[i].critbyte, path[i].critbit) {
+		i++
+	}
+	below := n
+	if i < len(path) {
+		below = path[i]
+	}
+	return t.withRoot(rebuildPath(path, i, key, insertAbove(below, newLeaf(key, value), critbyte, critbit, false), 1))
+}
+
+// SetIfAbsent sets the key to the given value only if the key doesn't exist yet.  Returns the
+// resulting trie and whether the value was set.
+func (t *TypedTrie[V]) SetIfAbsent(key []byte, value V) (*TypedTrie[V], bool) {
+	set := false
+	ret := t.alter(key, func(old V, exists bool) (V, bool, bool) {
+		if exists {
+			return old, true, false
+		}
+		set = true
+		return value, true, true
+	})
+	return ret, set
+}
+
+// ReplaceIf sets the key to @new only if it currently holds @expected, compared with ==.
+// Values which can't be compared, like slices, never match.  Returns the resulting trie and
+// whether the value matched and was replaced.
+func (t *TypedTrie[V]) ReplaceIf(key []byte, expected, new V) (*TypedTrie[V], bool) {
+	replaced := false
+	ret := t.alter(key, func(old V, exists bool) (V, bool, bool) {
+		if exists && sameValue(old, expected) {
+			replaced = true
+			return new, true, !sameValue(old, new)
+		}
+		return old, exists, false
+	})
+	return ret, replaced
+}
+
+func newLeaf[V any](key []byte, value V) *leaf[V] {
+	if any(value) == nil {
+		panic("value cannot be nil")
+	}
+	return &leaf[V]{
+		key:   key,
+		value: value,
+	}
+}
+
+// Copies the nodes on the path above index @i, pointing the bottom one at @replacement in place
+// of path[i] and adjusting each count by @delta.  Returns the new root.
+func rebuildPath[V any](path []*inner[V], i int, key []byte, replacement node[V], delta int) node[V] {
+	for i--; i >= 0; i-- {
+		n := path[i]
+		dir := findDirection(key, n.critbyte, n.critbit)
+		ret := &inner[V]{
+			critbyte: n.critbyte,
+			critbit:  n.critbit,
+			count:    uint32(int(n.count) + delta),
+		}
+		ret.children[dir] = replacement
+		ret.children[1-dir] = n.children[1-dir]
+		replacement = ret
+	}
+	return replacement
+}
+
+// Returns true if the two values are equal according to ==, or false if they can't be compared.
+func sameValue[V any](a, b V) bool {
+	x, y := any(a), any(b)
+	vx, vy := reflect.ValueOf(x), reflect.ValueOf(y)
+	if !vx.IsValid() || !vy.IsValid() || !vx.Comparable() || !vy.Comparable() {
+		return x == nil && y == nil
+	}
+	return x == y
+}
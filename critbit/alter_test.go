@@ -0,0 +1,155 @@
+package critbit
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func increment(old interface{}, exists bool) (interface{}, bool) {
+	if !exists {
+		return 1, true
+	}
+	return old.(int) + 1, true
+}
+
+func TestAlter_NilTrie_Inserts(t *testing.T) {
+
+	//act
+	instance := NilTrie().Alter([]byte("a"), increment)
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"a": 1}, trieToMap(instance))
+	assert.Equal(t, 0, NilTrie().Len(), "nil trie should remain immutable")
+}
+
+func TestAlter_UpdatesExisting(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	result := instance.Alter([]byte("bc"), increment)
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"ba": 1, "bc": 3, "d": 3}, trieToMap(result))
+	assert.Equal(t, map[string]interface{}{"ba": 1, "bc": 2, "d": 3}, trieToMap(instance))
+	assertCounts(t, result.root)
+}
+
+func TestAlter_Deletes(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	result := instance.Alter([]byte("bc"), func(old interface{}, exists bool) (interface{}, bool) {
+		assert.True(t, exists)
+		assert.Equal(t, 2, old)
+		return nil, false
+	})
+	single, _ := NilTrie().Set([]byte("a"), 1)
+	empty := single.Alter([]byte("a"), func(interface{}, bool) (interface{}, bool) { return nil, false })
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"ba": 1, "d": 3}, trieToMap(result))
+	assertCounts(t, result.root)
+	assert.Equal(t, 0, empty.Len())
+}
+
+func TestAlter_NoChange_ReturnsSameTrie(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	calls := 0
+
+	//act
+	missing := instance.Alter([]byte("b"), func(old interface{}, exists bool) (interface{}, bool) {
+		calls++
+		assert.False(t, exists)
+		assert.Nil(t, old)
+		return nil, false
+	})
+	same := instance.Alter([]byte("a"), func(old interface{}, exists bool) (interface{}, bool) {
+		calls++
+		return old, true
+	})
+
+	//assert
+	assert.Equal(t, 2, calls)
+	assert.True(t, instance == missing, "missing")
+	assert.True(t, instance == same, "same")
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+
+	//act
+	existing, existingSet := instance.SetIfAbsent([]byte("a"), 2)
+	added, addedSet := instance.SetIfAbsent([]byte("b"), 2)
+
+	//assert
+	assert.False(t, existingSet)
+	assert.True(t, instance == existing, "existing")
+	assert.True(t, addedSet)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, trieToMap(added))
+}
+
+func TestReplaceIf(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("s"), []int{1})
+
+	//act
+	replaced, ok := instance.ReplaceIf([]byte("a"), 1, 5)
+	mismatch, mismatchOk := instance.ReplaceIf([]byte("a"), 2, 5)
+	missing, missingOk := instance.ReplaceIf([]byte("b"), 1, 5)
+	slice, sliceOk := instance.ReplaceIf([]byte("s"), []int{1}, 5)
+
+	//assert
+	assert.True(t, ok)
+	assert.Equal(t, 5, trieToMap(replaced)["a"])
+	assert.False(t, mismatchOk)
+	assert.True(t, instance == mismatch, "mismatch")
+	assert.False(t, missingOk)
+	assert.True(t, instance == missing, "missing")
+	assert.False(t, sliceOk, "slices can't be compared")
+	assert.True(t, instance == slice, "slice")
+}
+
+func TestAlter_Random_MatchesSetAndDelete(t *testing.T) {
+	instance, sorted := randomTrie(500)
+	expected := instance
+
+	for i := 0; i < 2000; i++ {
+		key := randBytes()
+		if i%2 == 0 {
+			key = []byte(sorted[rand.Intn(len(sorted))])
+		}
+		remove := rand.Intn(3) == 0
+
+		//act
+		instance = instance.Alter(key, func(old interface{}, exists bool) (interface{}, bool) {
+			if remove {
+				return nil, false
+			}
+			return i, true
+		})
+
+		//assert
+		if remove {
+			expected, _ = expected.Delete(key)
+		} else {
+			expected, _ = expected.Set(key, i)
+		}
+		require.Equal(t, expected.Len(), instance.Len())
+	}
+	assert.Equal(t, trieToMap(expected), trieToMap(instance))
+	assertCounts(t, instance.root)
+	assert.Equal(t, expected.Hashed(hashInt).RootHash(), instance.Hashed(hashInt).RootHash(), "same structure")
+}
+
+func TestAlter_NilValue_Panics(t *testing.T) {
+	assertPanics(t, func() {
+		NilTrie().Alter([]byte("a"), func(interface{}, bool) (interface{}, bool) { return nil, true })
+	}, "alter")
+}
@@ -1,7 +1,11 @@
 package critbit
 
 import (
+	"bytes"
 	"crypto/rand"
+	mathrand "math/rand"
+	"runtime"
+	"slices"
 	"testing"
 )
 
@@ -236,6 +240,96 @@ func BenchmarkDelete_1kbyte_10kItems(b *testing.B) {
 	benchmarkDelete(b, 10*1000, 1024)
 }
 
+func benchmarkFixedSet_Add[K FixedKey](b *testing.B, numItems int) {
+	tree := NilFixedTrie[K, interface{}]()
+
+	for i := 0; i < numItems; i++ {
+		tree, _ = tree.Set(makeRandomFixedKey[K](), i)
+	}
+
+	keys := make([]K, 1000)
+	for i := 0; i < len(keys); i++ {
+		keys[i] = makeRandomFixedKey[K]()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tree.Set(keys[i%len(keys)], i)
+	}
+}
+
+func BenchmarkFixedSet_64bit_Add_100Items(b *testing.B) {
+	benchmarkFixedSet_Add[[8]byte](b, 100)
+}
+
+func BenchmarkFixedSet_64bit_Add_10kItems(b *testing.B) {
+	benchmarkFixedSet_Add[[8]byte](b, 10*1000)
+}
+
+func BenchmarkFixedSet_64bit_Add_100kItems(b *testing.B) {
+	benchmarkFixedSet_Add[[8]byte](b, 100*1000)
+}
+
+func BenchmarkFixedSet_128bit_Add_100Items(b *testing.B) {
+	benchmarkFixedSet_Add[[16]byte](b, 100)
+}
+
+func BenchmarkFixedSet_128bit_Add_10kItems(b *testing.B) {
+	benchmarkFixedSet_Add[[16]byte](b, 10*1000)
+}
+
+func BenchmarkFixedSet_128bit_Add_100kItems(b *testing.B) {
+	benchmarkFixedSet_Add[[16]byte](b, 100*1000)
+}
+
+func benchmarkFixedGet[K FixedKey](b *testing.B, numItems int) {
+	tree := NilFixedTrie[K, interface{}]()
+
+	keys := make([]K, numItems)
+	for i := 0; i < len(keys); i++ {
+		key := makeRandomFixedKey[K]()
+		keys[i] = key
+		tree, _ = tree.Set(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tree.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkFixedGet_64bit_100Items(b *testing.B) {
+	benchmarkFixedGet[[8]byte](b, 100)
+}
+
+func BenchmarkFixedGet_64bit_10kItems(b *testing.B) {
+	benchmarkFixedGet[[8]byte](b, 10*1000)
+}
+
+func BenchmarkFixedGet_64bit_100kItems(b *testing.B) {
+	benchmarkFixedGet[[8]byte](b, 100*1000)
+}
+
+func BenchmarkFixedGet_128bit_100Items(b *testing.B) {
+	benchmarkFixedGet[[16]byte](b, 100)
+}
+
+func BenchmarkFixedGet_128bit_10kItems(b *testing.B) {
+	benchmarkFixedGet[[16]byte](b, 10*1000)
+}
+
+func BenchmarkFixedGet_128bit_100kItems(b *testing.B) {
+	benchmarkFixedGet[[16]byte](b, 100*1000)
+}
+
+func makeRandomFixedKey[K FixedKey]() K {
+	var key K
+	for i := 0; i < len(key); i++ {
+		key[i] = byte(mathrand.Intn(256))
+	}
+	return key
+}
+
 func makeRandomKey(t *testing.B, keyLen int) []byte {
 	b := make([]byte, keyLen)
 	if _, err := rand.Read(b); err != nil {
@@ -298,3 +392,216 @@ func BenchmarkVisitAscend_1kbyte_100Items(b *testing.B) {
 func BenchmarkVisitAscend_1kbyte_10kItems(b *testing.B) {
 	benchmarkVisitAscend(b, 10*1000, 1024, nil)
 }
+
+func benchmarkVisitDescend(b *testing.B, numItems int, keyLen int) {
+	tree := NilTrie()
+
+	keys := make([][]byte, numItems)
+	for i := 0; i < len(keys); i++ {
+		key := makeRandomKey(b, keyLen)
+		keys[i] = key
+		tree, _ = tree.Set(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.VisitDescend(keys[i%len(keys)], func(key []byte, val interface{}) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkVisitDescend_32bit_SingleItem(b *testing.B) {
+	benchmarkVisitDescend(b, 1, 32/8)
+}
+
+func BenchmarkVisitDescend_32bit_100Items(b *testing.B) {
+	benchmarkVisitDescend(b, 100, 32/8)
+}
+
+func BenchmarkVisitDescend_32bit_10kItems(b *testing.B) {
+	benchmarkVisitDescend(b, 10*1000, 32/8)
+}
+
+func BenchmarkVisitDescend_64bit_SingleItem(b *testing.B) {
+	benchmarkVisitDescend(b, 1, 64/8)
+}
+
+func BenchmarkVisitDescend_64bit_100Items(b *testing.B) {
+	benchmarkVisitDescend(b, 100, 64/8)
+}
+
+func BenchmarkVisitDescend_64bit_10kItems(b *testing.B) {
+	benchmarkVisitDescend(b, 10*1000, 64/8)
+}
+
+func BenchmarkVisitDescend_1kbyte_SingleItem(b *testing.B) {
+	benchmarkVisitDescend(b, 1, 1024)
+}
+
+func BenchmarkVisitDescend_1kbyte_100Items(b *testing.B) {
+	benchmarkVisitDescend(b, 100, 1024)
+}
+
+func BenchmarkVisitDescend_1kbyte_10kItems(b *testing.B) {
+	benchmarkVisitDescend(b, 10*1000, 1024)
+}
+
+func benchmarkBuild(b *testing.B, numItems int, keyLen int, bulk bool) {
+	tree := NilTrie()
+	for i := 0; i < numItems; i++ {
+		tree, _ = tree.Set(makeRandomKey(b, keyLen), i)
+	}
+	entries := make([]Entry[interface{}], 0, numItems)
+	tree.VisitAscend(nil, func(key []byte, val interface{}) bool {
+		entries = append(entries, Entry[interface{}]{Key: key, Value: val})
+		return true
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bulk {
+			_, _ = FromSorted(entries)
+			continue
+		}
+		built := NilTrie()
+		for _, e := range entries {
+			built, _ = built.Set(e.Key, e.Value)
+		}
+	}
+}
+
+func BenchmarkBuild_Set_64bit_10kItems(b *testing.B) {
+	benchmarkBuild(b, 10*1000, 64/8, false)
+}
+
+func BenchmarkBuild_FromSorted_64bit_10kItems(b *testing.B) {
+	benchmarkBuild(b, 10*1000, 64/8, true)
+}
+
+func BenchmarkBuild_Set_64bit_100kItems(b *testing.B) {
+	benchmarkBuild(b, 100*1000, 64/8, false)
+}
+
+func BenchmarkBuild_FromSorted_64bit_100kItems(b *testing.B) {
+	benchmarkBuild(b, 100*1000, 64/8, true)
+}
+
+func BenchmarkBuild_Set_1kbyte_10kItems(b *testing.B) {
+	benchmarkBuild(b, 10*1000, 1024, false)
+}
+
+func BenchmarkBuild_FromSorted_1kbyte_10kItems(b *testing.B) {
+	benchmarkBuild(b, 10*1000, 1024, true)
+}
+
+func benchmarkBatchSet(b *testing.B, numItems int, batchSize int, keyLen int, transient bool) {
+	tree := NilTrie()
+	for i := 0; i < numItems; i++ {
+		tree, _ = tree.Set(makeRandomKey(b, keyLen), i)
+	}
+	keys := make([][]byte, batchSize)
+	for i := 0; i < len(keys); i++ {
+		keys[i] = makeRandomKey(b, keyLen)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if transient {
+			tr := tree.Transient()
+			for j, k := range keys {
+				tr.Set(k, j)
+			}
+			_ = tr.Persistent()
+			continue
+		}
+		batch := tree
+		for j, k := range keys {
+			batch, _ = batch.Set(k, j)
+		}
+	}
+}
+
+func BenchmarkBatchSet_Set_64bit_10kItems_Into100k(b *testing.B) {
+	benchmarkBatchSet(b, 100*1000, 10*1000, 64/8, false)
+}
+
+func BenchmarkBatchSet_Transient_64bit_10kItems_Into100k(b *testing.B) {
+	benchmarkBatchSet(b, 100*1000, 10*1000, 64/8, true)
+}
+
+// measures the heap used by the trie's nodes, not counting the keys and values themselves.
+func benchmarkMemory(b *testing.B, numItems int, keyLen int) {
+	entries := make([]Entry[interface{}], numItems)
+	for i := range entries {
+		entries[i] = Entry[interface{}]{Key: makeRandomKey(b, keyLen), Value: interface{}(i)}
+	}
+	slices.SortFunc(entries, func(x, y Entry[interface{}]) int { return bytes.Compare(x.Key, y.Key) })
+	entries = slices.CompactFunc(entries, func(x, y Entry[interface{}]) bool { return bytes.Equal(x.Key, y.Key) })
+
+	var stats runtime.MemStats
+	var perEntry float64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before := int64(stats.HeapAlloc)
+
+		tree, _ := FromSorted(entries)
+
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		perEntry = float64(int64(stats.HeapAlloc)-before) / float64(len(entries))
+		runtime.KeepAlive(tree)
+	}
+	b.ReportMetric(perEntry, "heap-B/entry")
+}
+
+func BenchmarkMemory_64bit_10kItems(b *testing.B) {
+	benchmarkMemory(b, 10*1000, 64/8)
+}
+
+func BenchmarkMemory_64bit_1MItems(b *testing.B) {
+	benchmarkMemory(b, 1000*1000, 64/8)
+}
+
+func BenchmarkMemory_128bit_100kItems(b *testing.B) {
+	benchmarkMemory(b, 100*1000, 128/8)
+}
+
+// measures the heap used by a fixed trie's nodes, which include its keys.
+func benchmarkFixedMemory[K FixedKey](b *testing.B, numItems int) {
+	keys := make([]K, numItems)
+	values := make([]interface{}, numItems)
+	for i := range keys {
+		keys[i], values[i] = makeRandomFixedKey[K](), interface{}(i)
+	}
+
+	var stats runtime.MemStats
+	var perEntry float64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before := int64(stats.HeapAlloc)
+
+		tree := NilFixedTrie[K, interface{}]()
+		for j, key := range keys {
+			tree, _ = tree.Set(key, values[j])
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		perEntry = float64(int64(stats.HeapAlloc)-before) / float64(tree.Len())
+		runtime.KeepAlive(tree)
+	}
+	b.ReportMetric(perEntry, "heap-B/entry")
+}
+
+func BenchmarkMemory_Fixed_64bit_100kItems(b *testing.B) {
+	benchmarkFixedMemory[[8]byte](b, 100*1000)
+}
+
+func BenchmarkMemory_Fixed_128bit_100kItems(b *testing.B) {
+	benchmarkFixedMemory[[16]byte](b, 100*1000)
+}
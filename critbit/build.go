@@ -0,0 +1,116 @@
+package critbit
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+)
+
+// A key-value pair in a trie.
+type Entry[V any] struct {
+	Key   []byte
+	Value V
+}
+
+/*
+FromSorted builds a trie out of entries which are already sorted in strictly ascending
+key order.  The tree is built bottom-up in O(n) without copying any paths, which is much
+faster than repeated calls to Set.  Returns an error if the keys are not strictly ascending.
+ex:
+
+	tree, err := critbit.FromSorted([]critbit.Entry[int]{
+		{Key: []byte("a"), Value: 1},
+		{Key: []byte("b"), Value: 2},
+	})
+*/
+func FromSorted[V any](entries []Entry[V]) (*TypedTrie[V], error) {
+	return FromSortedSeq(func(yield func([]byte, V) bool) {
+		for _, e := range entries {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	})
+}
+
+// FromSortedSeq builds a trie out of a sequence of key-value pairs in strictly ascending key
+// order, in the same way as FromSorted.  Iteration stops at the first key which is out of order.
+func FromSortedSeq[V any](seq iter.Seq2[[]byte, V]) (*TypedTrie[V], error) {
+	var b sortedBuilder[V]
+	var err error
+	seq(func(key []byte, value V) bool {
+		err = b.add(key, value)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TypedTrie[V]{root: b.finish()}, nil
+}
+
+// Builds a trie from sorted keys.  Each new key goes at the far right of the tree, so only
+// the right spine of the tree is incomplete at any point.
+type sortedBuilder[V any] struct {
+	// the nodes on the right spine, ordered root first.  Their 1 child is not yet set.
+	spine []*inner[V]
+	// the most recently added leaf, which is the 1 child of the last node on the spine.
+	last  *leaf[V]
+	index int
+}
+
+func (b *sortedBuilder[V]) add(key []byte, value V) error {
+	if key == nil {
+		return fmt.Errorf("entry %d: key cannot be nil", b.index)
+	}
+	if any(value) == nil {
+		return fmt.Errorf("entry %d: value cannot be nil", b.index)
+	}
+	l := &leaf[V]{
+		key:   key,
+		value: value,
+	}
+	b.index++
+	if b.last == nil {
+		b.last = l
+		return nil
+	}
+	if bytes.Compare(b.last.key, key) >= 0 {
+		return fmt.Errorf("entry %d: key %x is not greater than the previous key %x", b.index-1, key, b.last.key)
+	}
+
+	critbyte, critbit := findCritbit(key, b.last.key)
+	//everything on the spine below the new critbit becomes the 0 child of the new node.
+	left := b.finishSpine(critbyte, critbit)
+	n := &inner[V]{
+		critbyte: critbyte,
+		critbit:  critbit,
+	}
+	n.children[0] = left
+	b.spine = append(b.spine, n)
+	b.last = l
+	return nil
+}
+
+// completes the nodes on the spine whose critbit comes after the given critbit, returning the
+// topmost completed subtree.
+func (b *sortedBuilder[V]) finishSpine(critbyte int, critbit uint8) node[V] {
+	var child node[V] = b.last
+	for len(b.spine) > 0 {
+		top := b.spine[len(b.spine)-1]
+		if top.critbyte < critbyte || (top.critbyte == critbyte && top.critbit < critbit) {
+			break
+		}
+		b.spine = b.spine[:len(b.spine)-1]
+		top.children[1] = child
+		top.count = top.children[0].size() + child.size()
+		child = top
+	}
+	return child
+}
+
+func (b *sortedBuilder[V]) finish() node[V] {
+	if b.last == nil {
+		return nil
+	}
+	return b.finishSpine(-1, 0)
+}
@@ -0,0 +1,148 @@
+package critbit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSorted_Empty(t *testing.T) {
+
+	//act
+	result, err := FromSorted[int](nil)
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, 0, result.Len())
+	assert.Nil(t, result.root)
+}
+
+func TestFromSorted_SingleItem(t *testing.T) {
+
+	//act
+	result, err := FromSorted([]Entry[int]{{Key: []byte{0x01}, Value: 1}})
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, 1, result.Len())
+	assert.Equal(t, []byte{0x01}, result.root.(*leaf[int]).key)
+}
+
+func TestFromSorted_MatchesSet(t *testing.T) {
+	keys := [][]byte{
+		[]byte("abc/ghi"),
+		[]byte("abcd/a"),
+		[]byte("abcd/abcd1"),
+		[]byte("abcd/abcd2"),
+		[]byte("abcdefgh/abcd3"),
+	}
+	entries := make([]Entry[interface{}], len(keys))
+	expected := NilTrie()
+	for i, k := range keys {
+		entries[i] = Entry[interface{}]{Key: k, Value: i}
+		expected, _ = expected.Set(k, i)
+	}
+
+	//act
+	result, err := FromSorted(entries)
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, expected.DumpTrie(), result.DumpTrie())
+	assertCounts(t, result.root)
+}
+
+func TestFromSorted_NotAscending_Fails(t *testing.T) {
+
+	//act
+	result, err := FromSorted([]Entry[int]{
+		{Key: []byte{0x01}, Value: 1},
+		{Key: []byte{0x03}, Value: 3},
+		{Key: []byte{0x02}, Value: 2},
+	})
+
+	//assert
+	assert.Nil(t, result)
+	require.NotNil(t, err)
+	assert.Equal(t, "entry 2: key 02 is not greater than the previous key 03", err.Error())
+}
+
+func TestFromSorted_Duplicate_Fails(t *testing.T) {
+
+	//act
+	_, err := FromSorted([]Entry[int]{
+		{Key: []byte{0x01}, Value: 1},
+		{Key: []byte{0x01}, Value: 2},
+	})
+
+	//assert
+	assert.NotNil(t, err)
+}
+
+func TestFromSorted_NilValue_Fails(t *testing.T) {
+
+	//act
+	_, err := FromSorted([]Entry[interface{}]{{Key: []byte{0x01}, Value: nil}})
+
+	//assert
+	assert.NotNil(t, err)
+}
+
+func TestFromSortedSeq_StopsAtError(t *testing.T) {
+	yielded := 0
+	seq := func(yield func([]byte, int) bool) {
+		for _, b := range []byte{0x01, 0x00, 0x02} {
+			yielded++
+			if !yield([]byte{b}, int(b)) {
+				return
+			}
+		}
+	}
+
+	//act
+	_, err := FromSortedSeq(seq)
+
+	//assert
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, yielded, "should stop iterating at the bad key")
+}
+
+func TestFromSorted_Random_MatchesSet(t *testing.T) {
+	expected, sorted := randomTrie(1000)
+	entries := make([]Entry[interface{}], len(sorted))
+	for i, k := range sorted {
+		v, _ := expected.Get([]byte(k))
+		entries[i] = Entry[interface{}]{Key: []byte(k), Value: v}
+	}
+
+	//act
+	result, err := FromSorted(entries)
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, expected.DumpTrie(), result.DumpTrie())
+	assertCounts(t, result.root)
+
+	// the result should be a normal trie that can be modified
+	for _, k := range sorted[:100] {
+		result, _ = result.Delete([]byte(k))
+	}
+	assert.Equal(t, len(sorted)-100, result.Len())
+	assertCounts(t, result.root)
+}
+
+// checks that each node's count matches the number of leaves beneath it
+func assertCounts[V any](t *testing.T, n node[V]) uint32 {
+	if n == nil {
+		return 0
+	}
+	in, ok := n.(*inner[V])
+	if !ok {
+		assert.Equal(t, 1, n.size(), "leaf count")
+		return 1
+	}
+	count := assertCounts(t, in.children[0]) + assertCounts(t, in.children[1])
+	assert.Equal(t, count, in.count, "node count at (%d %x)", in.critbyte, in.critbit)
+	return count
+}
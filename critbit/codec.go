@@ -0,0 +1,8 @@
+package critbit
+
+// A ValueCodec converts the values of a trie to and from bytes, so that the trie can be
+// sent to another process or written to disk.
+type ValueCodec[V any] interface {
+	EncodeValue(value V) ([]byte, error)
+	DecodeValue(data []byte) (V, error)
+}
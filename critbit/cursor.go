@@ -0,0 +1,184 @@
+package critbit
+
+import (
+	"bytes"
+)
+
+/*
+A Cursor is a movable position within a snapshot of a trie.  Because the trie is immutable,
+a cursor remains valid no matter what is done to later versions of the trie.  A new cursor
+is not positioned on any key until one of First, Last or Seek is called, ex:
+
+	c := tree.Cursor()
+	for ok := c.Seek([]byte("b")); ok; ok = c.Next() {
+		fmt.Println(string(c.Key()), c.Value())
+	}
+
+A cursor can be moved onto a newer snapshot with Resume, and continues from the key it
+was last positioned on.
+*/
+type Cursor[V any] struct {
+	trie *TypedTrie[V]
+
+	// the path from the root to the current leaf.  Empty when not positioned on a key.
+	stack []node[V]
+
+	// the key that this cursor was positioned on when it was resumed.  Non-nil only until
+	// the cursor is moved.
+	resumed []byte
+}
+
+// Gets a new cursor over this snapshot of the trie.
+func (t *TypedTrie[V]) Cursor() *Cursor[V] {
+	return &Cursor[V]{trie: t}
+}
+
+// Gets the snapshot of the trie that this cursor iterates.
+func (c *Cursor[V]) Trie() *TypedTrie[V] {
+	return c.trie
+}
+
+// Returns true if the cursor is positioned on a key.
+func (c *Cursor[V]) Valid() bool {
+	return len(c.stack) > 0
+}
+
+// Gets the key at the cursor's position, or nil if it is not positioned on a key.
+func (c *Cursor[V]) Key() []byte {
+	if len(c.stack) == 0 {
+		return nil
+	}
+	return c.stack[len(c.stack)-1].(*leaf[V]).key
+}
+
+// Gets the value at the cursor's position, or the zero value if it is not positioned on a key.
+func (c *Cursor[V]) Value() V {
+	if len(c.stack) == 0 {
+		var zero V
+		return zero
+	}
+	return c.stack[len(c.stack)-1].(*leaf[V]).value
+}
+
+// Moves the cursor to the smallest key in the trie.  Returns false if the trie is empty.
+func (c *Cursor[V]) First() bool {
+	c.reset()
+	if c.trie.root == nil {
+		return false
+	}
+	c.pushEdge(c.trie.root, 0)
+	return true
+}
+
+// Moves the cursor to the largest key in the trie.  Returns false if the trie is empty.
+func (c *Cursor[V]) Last() bool {
+	c.reset()
+	if c.trie.root == nil {
+		return false
+	}
+	c.pushEdge(c.trie.root, 1)
+	return true
+}
+
+// Moves the cursor to the smallest key which is greater than or equal to the given key.
+// Returns false if there is no such key.
+func (c *Cursor[V]) Seek(key []byte) bool {
+	c.reset()
+	if c.trie.root == nil {
+		return false
+	}
+	c.pushBound(findBound(c.trie.root, key))
+	if bytes.Compare(c.Key(), key) < 0 {
+		return c.Next()
+	}
+	return true
+}
+
+// Moves the cursor to the next key in ascending order.  Returns false, leaving the cursor
+// unpositioned, if there are no more keys.  An unpositioned cursor moves to the first key.
+func (c *Cursor[V]) Next() bool {
+	if c.resumed != nil {
+		key := c.resumed
+		if c.Seek(key) && bytes.Equal(c.Key(), key) {
+			return c.Next()
+		}
+		return c.Valid()
+	}
+	if len(c.stack) == 0 {
+		return c.First()
+	}
+	return c.step(1)
+}
+
+// Moves the cursor to the previous key in ascending order.  Returns false, leaving the cursor
+// unpositioned, if there are no more keys.  An unpositioned cursor moves to the last key.
+func (c *Cursor[V]) Prev() bool {
+	if c.resumed != nil {
+		key := c.resumed
+		c.reset()
+		if c.trie.root == nil {
+			return false
+		}
+		c.pushBound(findBound(c.trie.root, key))
+		if bytes.Compare(c.Key(), key) >= 0 {
+			return c.step(0)
+		}
+		return true
+	}
+	if len(c.stack) == 0 {
+		return c.Last()
+	}
+	return c.step(0)
+}
+
+// Gets a new cursor over the given snapshot, continuing from this cursor's key.  The new
+// cursor is not positioned on a key, but Next moves it to the first key greater than this
+// cursor's key and Prev to the last key less than it, whether or not that key still exists.
+// If this cursor is not positioned on a key, the new cursor is simply unpositioned.
+func (c *Cursor[V]) Resume(t *TypedTrie[V]) *Cursor[V] {
+	return &Cursor[V]{
+		trie:    t,
+		resumed: c.Key(),
+	}
+}
+
+func (c *Cursor[V]) reset() {
+	c.stack = c.stack[:0]
+	c.resumed = nil
+}
+
+// pushes the path from n to its edge-most leaf in the given direction
+func (c *Cursor[V]) pushEdge(n node[V], direction int) {
+	c.stack = append(c.stack, n)
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		n = in.children[direction]
+		c.stack = append(c.stack, n)
+	}
+}
+
+// pushes the path from the root to the leaf adjacent to the bound.  The bound lies between
+// this leaf and the next leaf in one direction or the other.
+func (c *Cursor[V]) pushBound(b *bound) {
+	n := c.trie.root
+	c.stack = append(c.stack, n)
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		n = in.children[b.direction(in.critbyte, in.critbit)]
+		c.stack = append(c.stack, n)
+	}
+}
+
+// moves to the adjacent leaf in the given direction, by going back up the path until we can
+// go down the other side of a node.
+func (c *Cursor[V]) step(direction int) bool {
+	for len(c.stack) > 1 {
+		child := c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+		parent := c.stack[len(c.stack)-1].(*inner[V])
+		if parent.children[1-direction] == child {
+			c.pushEdge(parent.children[direction], 1-direction)
+			return true
+		}
+	}
+	c.stack = c.stack[:0]
+	return false
+}
@@ -0,0 +1,172 @@
+package critbit
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_NilTrie(t *testing.T) {
+	c := NilTrie().Cursor()
+
+	//act & assert
+	assert.False(t, c.First(), "first")
+	assert.False(t, c.Last(), "last")
+	assert.False(t, c.Seek([]byte{0x01}), "seek")
+	assert.False(t, c.Next(), "next")
+	assert.False(t, c.Prev(), "prev")
+	assert.False(t, c.Valid(), "valid")
+	assert.Nil(t, c.Key())
+	assert.Nil(t, c.Value())
+}
+
+func TestCursor_Root(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	c := instance.Cursor()
+
+	//act
+	ok := c.First()
+
+	//assert
+	require.True(t, ok)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, c.Key())
+	assert.Equal(t, 123, c.Value())
+	assert.False(t, c.Next(), "no next")
+	assert.False(t, c.Valid(), "past the end")
+}
+
+func TestCursor_NextAndPrev(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("c"), 3)
+	c := instance.Cursor()
+
+	//act
+	require.True(t, c.First())
+	require.True(t, c.Next())
+	require.True(t, c.Next())
+	atLast := string(c.Key())
+	require.True(t, c.Prev())
+	back := string(c.Key())
+
+	//assert
+	assert.Equal(t, "c", atLast)
+	assert.Equal(t, "b", back)
+	require.True(t, c.Prev())
+	assert.Equal(t, "a", string(c.Key()))
+	assert.False(t, c.Prev(), "before the start")
+	assert.True(t, c.Prev(), "unpositioned cursor should wrap to last")
+	assert.Equal(t, "c", string(c.Key()))
+}
+
+func TestCursor_Seek(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+	c := instance.Cursor()
+
+	//act & assert
+	require.True(t, c.Seek([]byte("a\x02")))
+	assert.Equal(t, "ba", string(c.Key()))
+	require.True(t, c.Seek([]byte("bc")))
+	assert.Equal(t, "bc", string(c.Key()))
+	require.True(t, c.Seek([]byte("bca")))
+	assert.Equal(t, "d", string(c.Key()))
+	assert.False(t, c.Seek([]byte("e")))
+}
+
+func TestCursor_Resume_KeyStillExists(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("c"), 3)
+	c := instance.Cursor()
+	require.True(t, c.Seek([]byte("b")))
+
+	newer, _ := instance.Set([]byte("bb"), 22)
+
+	//act
+	resumed := c.Resume(newer)
+
+	//assert
+	assert.False(t, resumed.Valid(), "should not be positioned")
+	require.True(t, resumed.Next())
+	assert.Equal(t, "bb", string(resumed.Key()))
+	assert.Equal(t, newer, resumed.Trie())
+	assert.Equal(t, "b", string(c.Key()), "original cursor should be unaffected")
+	require.True(t, c.Next())
+	assert.Equal(t, "c", string(c.Key()), "original cursor should iterate the old snapshot")
+}
+
+func TestCursor_Resume_KeyDeleted(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("c"), 3)
+	c := instance.Cursor()
+	require.True(t, c.Seek([]byte("b")))
+
+	newer, _ := instance.Delete([]byte("b"))
+
+	//act
+	forward := c.Resume(newer)
+	backward := c.Resume(newer)
+
+	//assert
+	require.True(t, forward.Next())
+	assert.Equal(t, "c", string(forward.Key()))
+	require.True(t, backward.Prev())
+	assert.Equal(t, "a", string(backward.Key()))
+}
+
+func TestCursor_Random_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(500)
+	c := instance.Cursor()
+
+	//forwards
+	i := 0
+	for ok := c.First(); ok; ok = c.Next() {
+		require.Equal(t, sorted[i], string(c.Key()))
+		i++
+	}
+	assert.Equal(t, len(sorted), i)
+
+	//backwards
+	for ok := c.Last(); ok; ok = c.Prev() {
+		i--
+		require.Equal(t, sorted[i], string(c.Key()))
+	}
+	assert.Equal(t, 0, i)
+
+	//seeking
+	for j := 0; j < 100; j++ {
+		key := randBytes()
+		idx := sort.SearchStrings(sorted, string(key))
+
+		ok := c.Seek(key)
+
+		require.Equal(t, idx < len(sorted), ok, "seek %x", key)
+		if ok {
+			assert.Equal(t, sorted[idx], string(c.Key()))
+		}
+	}
+
+	//resuming from a random key, in both directions
+	for j := 0; j < 100; j++ {
+		idx := rand.Intn(len(sorted))
+		require.True(t, c.Seek([]byte(sorted[idx])))
+		deleted, _ := instance.Delete([]byte(sorted[idx]))
+
+		forward, backward := c.Resume(deleted), c.Resume(deleted)
+
+		require.Equal(t, idx+1 < len(sorted), forward.Next())
+		if idx+1 < len(sorted) {
+			assert.Equal(t, sorted[idx+1], string(forward.Key()))
+		}
+		require.Equal(t, idx > 0, backward.Prev())
+		if idx > 0 {
+			assert.Equal(t, sorted[idx-1], string(backward.Key()))
+		}
+	}
+}
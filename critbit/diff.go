@@ -0,0 +1,123 @@
+package critbit
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// The kind of change between two tries reported by Diff.
+type ChangeType int
+
+const (
+	// The key exists only in the new trie.
+	Added ChangeType = iota + 1
+	// The key exists only in the old trie.
+	Removed
+	// The key exists in both tries with different values.
+	Changed
+)
+
+func (c ChangeType) String() string {
+	switch c {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Changed:
+		return "Changed"
+	}
+	return "Unknown"
+}
+
+/*
+Diff applies the visitor function to every key which differs between the old and new tries,
+in ascending key order.  For Added keys the old value is the zero value, and for Removed keys
+the new value is the zero value.  The visitor's boolean return value indicates whether to continue.
+
+Subtrees shared between the two tries are skipped, so comparing a trie with one derived from it
+costs time proportional to the size of the change.  The @eq function decides whether two values
+under the same key are equal.  If it is nil, values are compared with ==, which requires V to be
+a comparable type, and Diff panics if it is not.  When V is an interface type, values whose
+dynamic type is not comparable are never equal. ex:
+
+	critbit.Diff(before, after, nil, func(change critbit.ChangeType, key []byte, old, new interface{}) bool {
+		fmt.Printf("%v %s: %v -> %v\n", change, key, old, new)
+		return true
+	})
+*/
+func Diff[V any](old, new *TypedTrie[V], eq func(V, V) bool, visitor func(change ChangeType, key []byte, oldValue, newValue V) bool) {
+	d := &differ[V]{eq: defaultEq(eq, "Diff"), visitor: visitor}
+	d.diff(old.root, new.root)
+}
+
+type differ[V any] struct {
+	eq      func(V, V) bool
+	visitor func(ChangeType, []byte, V, V) bool
+}
+
+// Gets the equality function used by @op when the caller gave @eq.  A nil @eq falls back to ==,
+// which is only possible when V is comparable, so @op panics for any other type.
+func defaultEq[V any](eq func(V, V) bool, op string) func(V, V) bool {
+	if eq != nil {
+		return eq
+	}
+	if t := reflect.TypeFor[V](); !t.Comparable() {
+		panic(fmt.Sprintf("critbit: %s needs an eq function for values of type %v", op, t))
+	}
+	return sameValue[V]
+}
+
+// walks two subtrees together, returning false if the visitor asked to stop.
+func (d *differ[V]) diff(a, b node[V]) bool {
+	if a == b {
+		return true
+	}
+	if a == nil {
+		return d.all(b, Added)
+	}
+	if b == nil {
+		return d.all(a, Removed)
+	}
+
+	o, _, _, dir := findOverlap(a, b)
+	switch o {
+	case sameKey:
+		leafA, leafB := a.(*leaf[V]), b.(*leaf[V])
+		if d.eq(leafA.value, leafB.value) {
+			return true
+		}
+		return d.visitor(Changed, leafA.key, leafA.value, leafB.value)
+	case disjoint:
+		if dir == 0 {
+			return d.all(a, Removed) && d.all(b, Added)
+		}
+		return d.all(b, Added) && d.all(a, Removed)
+	case aligned:
+		innerA, innerB := a.(*inner[V]), b.(*inner[V])
+		return d.diff(innerA.children[0], innerB.children[0]) && d.diff(innerA.children[1], innerB.children[1])
+	case insideA:
+		innerA := a.(*inner[V])
+		if dir == 0 {
+			return d.diff(innerA.children[0], b) && d.all(innerA.children[1], Removed)
+		}
+		return d.all(innerA.children[0], Removed) && d.diff(innerA.children[1], b)
+	default:
+		innerB := b.(*inner[V])
+		if dir == 0 {
+			return d.diff(a, innerB.children[0]) && d.all(innerB.children[1], Added)
+		}
+		return d.all(innerB.children[0], Added) && d.diff(a, innerB.children[1])
+	}
+}
+
+// reports every key in the subtree as the given change.
+func (d *differ[V]) all(n node[V], change ChangeType) bool {
+	var zero V
+	result, _ := n.visitAscend(nil, func(key []byte, value V) bool {
+		if change == Added {
+			return d.visitor(Added, key, zero, value)
+		}
+		return d.visitor(Removed, key, value, zero)
+	}, false)
+	return result
+}
@@ -0,0 +1,211 @@
+package critbit
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type change struct {
+	change   ChangeType
+	key      string
+	old, new interface{}
+}
+
+func TestDiff_NilTries(t *testing.T) {
+
+	//act
+	changes := diffToSlice(NilTrie(), NilTrie())
+
+	//assert
+	assert.Equal(t, 0, len(changes))
+}
+
+func TestDiff_AddedRemovedChanged(t *testing.T) {
+	old, _ := NilTrie().Set([]byte("a"), 1)
+	old, _ = old.Set([]byte("b"), 2)
+	old, _ = old.Set([]byte("c"), 3)
+	new, _ := old.Delete([]byte("a"))
+	new, _ = new.Set([]byte("b"), 22)
+	new, _ = new.Set([]byte("bb"), 4)
+	new, _ = new.Set([]byte("c"), 3)
+
+	//act
+	changes := diffToSlice(old, new)
+
+	//assert
+	require.Equal(t, 3, len(changes), "len")
+	assert.Equal(t, change{Removed, "a", 1, nil}, changes[0])
+	assert.Equal(t, change{Changed, "b", 2, 22}, changes[1])
+	assert.Equal(t, change{Added, "bb", nil, 4}, changes[2])
+}
+
+func TestDiff_DisjointTries(t *testing.T) {
+	old, _ := NilTrie().Set([]byte("ba"), 1)
+	old, _ = old.Set([]byte("bb"), 2)
+	new, _ := NilTrie().Set([]byte("aa"), 3)
+	new, _ = new.Set([]byte("ca"), 4)
+
+	//act
+	changes := diffToSlice(old, new)
+
+	//assert
+	require.Equal(t, 4, len(changes), "len")
+	assert.Equal(t, change{Added, "aa", nil, 3}, changes[0])
+	assert.Equal(t, change{Removed, "ba", 1, nil}, changes[1])
+	assert.Equal(t, change{Removed, "bb", 2, nil}, changes[2])
+	assert.Equal(t, change{Added, "ca", nil, 4}, changes[3])
+}
+
+func TestDiff_NilEq_ComparesWithEquals(t *testing.T) {
+	old, _ := NilTrie().Set([]byte("a"), 1)
+	old, _ = old.Set([]byte("b"), 2)
+	new, _ := old.Set([]byte("a"), 1)
+	new, _ = new.Set([]byte("b"), 22)
+
+	//act
+	var changes []string
+	Diff(old, new, nil, func(change ChangeType, key []byte, oldValue, newValue interface{}) bool {
+		changes = append(changes, string(key))
+		return true
+	})
+
+	//assert
+	assert.Equal(t, []string{"b"}, changes)
+}
+
+func TestDiff_NilEq_NotComparable_Panics(t *testing.T) {
+	old, _ := NilTypedTrie[[]int]().Set([]byte("a"), []int{1})
+	new, _ := old.Set([]byte("a"), []int{1})
+
+	//act & assert
+	assertPanics(t, func() {
+		Diff(old, new, nil, func(change ChangeType, key []byte, oldValue, newValue []int) bool {
+			return true
+		})
+	}, "diff of slices")
+}
+
+func TestDiff_StopsWhenVisitorReturnsFalse(t *testing.T) {
+	old, _ := NilTrie().Set([]byte("a"), 1)
+	new, _ := NilTrie().Set([]byte("b"), 2)
+	new, _ = new.Set([]byte("c"), 3)
+
+	//act
+	count := 0
+	Diff(old, new, intEq, func(change ChangeType, key []byte, oldValue, newValue interface{}) bool {
+		count++
+		return false
+	})
+
+	//assert
+	assert.Equal(t, 1, count)
+}
+
+func TestDiff_SkipsSharedSubtrees(t *testing.T) {
+	old, sorted := randomTrie(10000)
+	new, _ := old.Set([]byte(sorted[rand.Intn(len(sorted))]), -1)
+
+	//act
+	compared := 0
+	changes := 0
+	Diff(old, new, func(a, b interface{}) bool {
+		compared++
+		return a == b
+	}, func(change ChangeType, key []byte, oldValue, newValue interface{}) bool {
+		changes++
+		return true
+	})
+
+	//assert
+	assert.Equal(t, 1, compared, "only the changed leaf should be compared")
+	assert.Equal(t, 1, changes)
+}
+
+func TestDiff_Random_MatchesMaps(t *testing.T) {
+	for round := 0; round < 10; round++ {
+		old, sorted := randomTrie(500)
+		new := old
+		if round%2 == 1 {
+			//unrelated tries share no structure
+			new, _ = randomTrie(500)
+		}
+		for i := 0; i < 100; i++ {
+			switch rand.Intn(3) {
+			case 0:
+				new, _ = new.Delete([]byte(sorted[rand.Intn(len(sorted))]))
+			case 1:
+				new, _ = new.Set([]byte(sorted[rand.Intn(len(sorted))]), -i)
+			default:
+				new, _ = new.Set(randBytes(), i)
+			}
+		}
+
+		expected := make([]change, 0)
+		oldMap, newMap := trieToMap(old), trieToMap(new)
+		all := mergedKeys(old, new)
+		for _, k := range all {
+			o, inOld := oldMap[k]
+			n, inNew := newMap[k]
+			switch {
+			case !inOld:
+				expected = append(expected, change{Added, k, nil, n})
+			case !inNew:
+				expected = append(expected, change{Removed, k, o, nil})
+			case o != n:
+				expected = append(expected, change{Changed, k, o, n})
+			}
+		}
+
+		//act
+		changes := diffToSlice(old, new)
+
+		//assert
+		require.Equal(t, len(expected), len(changes), "len")
+		for i := range changes {
+			assert.Equal(t, expected[i], changes[i])
+		}
+	}
+}
+
+func intEq(a, b interface{}) bool {
+	return a == b
+}
+
+func diffToSlice(old, new *Trie) []change {
+	ret := make([]change, 0)
+	Diff(old, new, intEq, func(c ChangeType, key []byte, oldValue, newValue interface{}) bool {
+		ret = append(ret, change{c, string(key), oldValue, newValue})
+		return true
+	})
+	return ret
+}
+
+func trieToMap(t *Trie) map[string]interface{} {
+	ret := make(map[string]interface{})
+	t.VisitAscend(nil, func(key []byte, val interface{}) bool {
+		ret[string(key)] = val
+		return true
+	})
+	return ret
+}
+
+// gets the keys of both tries, in ascending order.
+func mergedKeys(a, b *Trie) []string {
+	ka, kb := visitToSlice(a, nil), visitToSlice(b, nil)
+	ret := make([]string, 0, len(ka)+len(kb))
+	for len(ka) > 0 || len(kb) > 0 {
+		switch {
+		case len(kb) == 0 || (len(ka) > 0 && bytes.Compare(ka[0], kb[0]) < 0):
+			ret, ka = append(ret, string(ka[0])), ka[1:]
+		case len(ka) == 0 || bytes.Compare(ka[0], kb[0]) > 0:
+			ret, kb = append(ret, string(kb[0])), kb[1:]
+		default:
+			ret, ka, kb = append(ret, string(ka[0])), ka[1:], kb[1:]
+		}
+	}
+	return ret
+}
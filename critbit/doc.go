@@ -30,6 +30,12 @@ Removing an item from the tree
 	got, ok = tree.Get([]byte{0x01})
 	//got == nil, ok == false
 
+Using a typed trie to avoid type assertions -
+	typed := critbit.NilTypedTrie[int]()
+	typed, _ = typed.Set([]byte{0x01}, 1)
+	n, ok := typed.Get([]byte{0x01})
+	//n == 1, ok == true
+
 Some benchmarks on my Macbook Pro 2.5GHz Intel Core i7, 16GB 1600MHz DDR3
 
 	BenchmarkSet_32bit_Add_EmptyTree	 5000000	       269 ns/op
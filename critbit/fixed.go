@@ -0,0 +1,219 @@
+package critbit
+
+import (
+	"iter"
+)
+
+// The key types a FixedTrie can hold: byte arrays of one of these widths, or any named type
+// based on one, like a UUID type defined as [16]byte.
+type FixedKey interface {
+	~[4]byte | ~[8]byte | ~[12]byte | ~[16]byte | ~[20]byte | ~[32]byte
+}
+
+/*
+FixedTrie is a copy-on-write critbit trie for keys which are all the same width, such as 8 byte
+IDs or 16 byte UUIDs.  Keys are stored inline in the leaves rather than as separate byte slices,
+and since no key can be a prefix of another, the nodes never split on key length.  That makes it
+smaller and faster than a TypedTrie for the same keys, and its keys visit in the same order
+as bytes.Compare, ex:
+
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], 42)
+	users := critbit.NilFixedTrie[[8]byte, *User]()
+	users, _ = users.Set(id, user)
+	user, ok := users.Get(id)
+
+The zero value of a FixedTrie is also an empty trie.
+*/
+type FixedTrie[K FixedKey, V any] struct {
+	root fixedNode[K, V]
+}
+
+// A node in a FixedTrie, either a *fixedLeaf or a *fixedInner.
+type fixedNode[K FixedKey, V any] interface {
+	// gets the number of leaves in this subtree.
+	size() uint32
+}
+
+type fixedLeaf[K FixedKey, V any] struct {
+	key   K
+	value V
+}
+
+type fixedInner[K FixedKey, V any] struct {
+	// the index of the byte containing the critical bit.
+	critbyte int
+	// a bitmask where all bits except the critical bit are true, see node.
+	critbit uint8
+
+	count uint32
+
+	children [2]fixedNode[K, V]
+}
+
+func (l *fixedLeaf[K, V]) size() uint32 {
+	return 1
+}
+
+func (n *fixedInner[K, V]) size() uint32 {
+	return n.count
+}
+
+// Gets an empty trie with keys of type K holding values of type V.
+func NilFixedTrie[K FixedKey, V any]() *FixedTrie[K, V] {
+	return &FixedTrie[K, V]{}
+}
+
+// Gets the number of items in the trie.
+func (t *FixedTrie[K, V]) Len() uint32 {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.size()
+}
+
+// Gets an item out of the trie by its key.  Returns the item and a boolean which is
+// true if the item existed.
+func (t *FixedTrie[K, V]) Get(key K) (V, bool) {
+	if t.root != nil {
+		if l := fixedBestLeaf[K, V](t.root, key); l.key == key {
+			return l.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Returns a new trie with the given key set to the given value, along with the previous value.
+func (t *FixedTrie[K, V]) Set(key K, value V) (*FixedTrie[K, V], V) {
+	var zero V
+	if any(value) == nil {
+		panic("value cannot be nil")
+	}
+	l := &fixedLeaf[K, V]{key: key, value: value}
+	if t.root == nil {
+		return &FixedTrie[K, V]{root: l}, zero
+	}
+
+	best := fixedBestLeaf[K, V](t.root, key)
+	if best.key == key {
+		return &FixedTrie[K, V]{root: fixedInsert(t.root, l, 0, 0, true)}, best.value
+	}
+	critbyte, critbit := fixedCritbit(key, best.key)
+	return &FixedTrie[K, V]{root: fixedInsert(t.root, l, critbyte, critbit, false)}, zero
+}
+
+// Deletes the key-value pair for the given key out of the trie, returning the deleted value.
+// If the key doesn't exist, this trie is returned.
+func (t *FixedTrie[K, V]) Delete(key K) (*FixedTrie[K, V], V) {
+	var zero V
+	if t.root == nil {
+		return t, zero
+	}
+	best := fixedBestLeaf[K, V](t.root, key)
+	if best.key != key {
+		return t, zero
+	}
+	return &FixedTrie[K, V]{root: fixedDelete[K, V](t.root, key)}, best.value
+}
+
+// Applies the visitor function to all key-value pairs in the trie in ascending key order.
+// The visitor's boolean return value indicates whether to continue.
+func (t *FixedTrie[K, V]) VisitAscend(visitor func(K, V) bool) {
+	if t.root != nil {
+		fixedVisit(t.root, visitor)
+	}
+}
+
+// Gets an iterator over the key-value pairs in the trie in ascending key order.
+func (t *FixedTrie[K, V]) All() iter.Seq2[K, V] {
+	return t.VisitAscend
+}
+
+//-- internal functions --//
+
+func fixedBestLeaf[K FixedKey, V any](n fixedNode[K, V], key K) *fixedLeaf[K, V] {
+	for {
+		in, ok := n.(*fixedInner[K, V])
+		if !ok {
+			return n.(*fixedLeaf[K, V])
+		}
+		n = in.children[fixedDirection(key, in.critbyte, in.critbit)]
+	}
+}
+
+// Copies the path down to the leaf's key.  If @replace is true the key exists and its leaf
+// is replaced, otherwise the leaf is added in a new node at the given critbit.
+func fixedInsert[K FixedKey, V any](n fixedNode[K, V], l *fixedLeaf[K, V], critbyte int, critbit uint8, replace bool) fixedNode[K, V] {
+	in, ok := n.(*fixedInner[K, V])
+	if replace && !ok {
+		return l
+	}
+	if !replace && (!ok || positionLess(critbyte, critbit, in.critbyte, in.critbit)) {
+		//this is the leaf we calculated the critbit from OR
+		//this node's critbit is bigger than the one we're trying to add, add a node before it
+		dir := fixedDirection(l.key, critbyte, critbit)
+		ret := &fixedInner[K, V]{
+			critbyte: critbyte,
+			critbit:  critbit,
+			count:    n.size() + 1,
+		}
+		ret.children[dir] = l
+		ret.children[1-dir] = n
+		return ret
+	}
+
+	dir := fixedDirection(l.key, in.critbyte, in.critbit)
+	ret := *in
+	ret.children[dir] = fixedInsert(in.children[dir], l, critbyte, critbit, replace)
+	if !replace {
+		ret.count++
+	}
+	return &ret
+}
+
+// Copies the path down to the key, which must exist, without its leaf.
+func fixedDelete[K FixedKey, V any](n fixedNode[K, V], key K) fixedNode[K, V] {
+	in, ok := n.(*fixedInner[K, V])
+	if !ok {
+		return nil
+	}
+	dir := fixedDirection(key, in.critbyte, in.critbit)
+	child := fixedDelete[K, V](in.children[dir], key)
+	if child == nil {
+		//the leaf was deleted - this node is no longer necessary
+		return in.children[1-dir]
+	}
+	ret := *in
+	ret.children[dir] = child
+	ret.count--
+	return &ret
+}
+
+func fixedVisit[K FixedKey, V any](n fixedNode[K, V], visitor func(K, V) bool) bool {
+	in, ok := n.(*fixedInner[K, V])
+	if !ok {
+		l := n.(*fixedLeaf[K, V])
+		return visitor(l.key, l.value)
+	}
+	return fixedVisit(in.children[0], visitor) && fixedVisit(in.children[1], visitor)
+}
+
+// Gets the direction of the key at a critical bit.  Unlike findDirection, there is no special
+// case for the key's length.
+func fixedDirection[K FixedKey](key K, critbyte int, critbit uint8) int {
+	return 1 - int((1+(critbit|key[critbyte]))>>7)
+}
+
+// Finds the critical bit of two different keys, in the same form as findCritbit.
+func fixedCritbit[K FixedKey](u, p K) (int, uint8) {
+	i := 0
+	for u[i] == p[i] {
+		i++
+	}
+	diff := u[i] ^ p[i]
+	diff |= diff >> 1
+	diff |= diff >> 2
+	diff |= diff >> 4
+	return i, ^(diff ^ (diff >> 1))
+}
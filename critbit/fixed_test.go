@@ -0,0 +1,165 @@
+package critbit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type uuid [16]byte
+
+func id(n uint64) [8]byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], n)
+	return key
+}
+
+// checks that each node's count matches the number of leaves beneath it
+func assertFixedCounts[K FixedKey, V any](t *testing.T, n fixedNode[K, V]) uint32 {
+	in, ok := n.(*fixedInner[K, V])
+	if !ok {
+		return 1
+	}
+	count := assertFixedCounts[K, V](t, in.children[0]) + assertFixedCounts[K, V](t, in.children[1])
+	assert.Equal(t, count, in.count, "node count at (%d %x)", in.critbyte, in.critbit)
+	return count
+}
+
+func TestFixedTrie_NilTrie(t *testing.T) {
+	instance := NilFixedTrie[[8]byte, int]()
+
+	//act
+	_, ok := instance.Get(id(1))
+	deleted, _ := instance.Delete(id(1))
+
+	//assert
+	assert.False(t, ok)
+	assert.Equal(t, 0, instance.Len())
+	assert.True(t, instance == deleted, "deleting from an empty trie")
+}
+
+func TestFixedTrie_SetGetDelete(t *testing.T) {
+	instance, _ := NilFixedTrie[[8]byte, string]().Set(id(1), "one")
+	instance, _ = instance.Set(id(3), "three")
+
+	//act
+	result, old := instance.Set(id(2), "two")
+	replaced, oldTwo := result.Set(id(2), "TWO")
+	deleted, oldThree := replaced.Delete(id(3))
+
+	//assert
+	assert.Equal(t, "", old)
+	assert.Equal(t, "two", oldTwo)
+	assert.Equal(t, "three", oldThree)
+	assert.Equal(t, 2, instance.Len(), "original trie should remain immutable")
+	assert.Equal(t, 3, result.Len())
+	assert.Equal(t, 3, replaced.Len())
+	assert.Equal(t, 2, deleted.Len())
+
+	got, ok := result.Get(id(2))
+	assert.True(t, ok)
+	assert.Equal(t, "two", got)
+	got, _ = replaced.Get(id(2))
+	assert.Equal(t, "TWO", got)
+	_, ok = deleted.Get(id(3))
+	assert.False(t, ok)
+	_, ok = instance.Get(id(2))
+	assert.False(t, ok, "original trie should remain immutable")
+}
+
+func TestFixedTrie_Set_NilValue_Panics(t *testing.T) {
+
+	//act & assert
+	assert.Panics(t, func() {
+		NilFixedTrie[[8]byte, interface{}]().Set(id(1), nil)
+	})
+}
+
+func TestFixedTrie_Delete_Missing_ReturnsSameTrie(t *testing.T) {
+	instance, _ := NilFixedTrie[[8]byte, int]().Set(id(1), 1)
+	instance, _ = instance.Set(id(2), 2)
+
+	//act
+	result, old := instance.Delete(id(3))
+
+	//assert
+	assert.True(t, instance == result, "deleting a missing key")
+	assert.Equal(t, 0, old)
+}
+
+func TestFixedTrie_NamedKeyType(t *testing.T) {
+	a, b := uuid{0x01}, uuid{0x01, 0x80}
+	instance, _ := NilFixedTrie[uuid, int]().Set(b, 2)
+	instance, _ = instance.Set(a, 1)
+
+	//act
+	keys := make([]uuid, 0)
+	instance.VisitAscend(func(key uuid, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	//assert
+	assert.Equal(t, []uuid{a, b}, keys)
+}
+
+func TestFixedTrie_MatchesTypedTrie(t *testing.T) {
+	fixed := NilFixedTrie[[4]byte, int]()
+	typed := NilTypedTrie[int]()
+	for i := 0; i < 2000; i++ {
+		// a small key space so that some keys are replaced and deleted
+		key := [4]byte{byte(rand.Intn(4)), byte(rand.Intn(256)), 0x00, byte(rand.Intn(4))}
+		if rand.Intn(3) == 0 {
+			var fixedOld, typedOld int
+			fixed, fixedOld = fixed.Delete(key)
+			typed, typedOld = typed.Delete(key[:])
+			require.Equal(t, typedOld, fixedOld)
+			continue
+		}
+		var fixedOld, typedOld int
+		fixed, fixedOld = fixed.Set(key, i)
+		typed, typedOld = typed.Set(append([]byte(nil), key[:]...), i)
+		require.Equal(t, typedOld, fixedOld)
+	}
+
+	//act
+	fixedKeys := make([][]byte, 0)
+	fixed.VisitAscend(func(key [4]byte, value int) bool {
+		fixedKeys = append(fixedKeys, append([]byte(nil), key[:]...))
+		got, ok := typed.Get(key[:])
+		assert.True(t, ok)
+		assert.Equal(t, got, value)
+		return true
+	})
+
+	//assert
+	require.Equal(t, typed.Len(), fixed.Len())
+	assert.True(t, sort.SliceIsSorted(fixedKeys, func(i, j int) bool {
+		return bytes.Compare(fixedKeys[i], fixedKeys[j]) < 0
+	}), "keys should visit in ascending order")
+	assertFixedCounts[[4]byte, int](t, fixed.root)
+}
+
+func TestFixedTrie_All_StopsEarly(t *testing.T) {
+	instance := NilFixedTrie[[8]byte, int]()
+	for i := uint64(0); i < 10; i++ {
+		instance, _ = instance.Set(id(i), int(i))
+	}
+
+	//act
+	values := make([]int, 0)
+	for _, v := range instance.All() {
+		values = append(values, v)
+		if len(values) == 3 {
+			break
+		}
+	}
+
+	//assert
+	assert.Equal(t, []int{0, 1, 2}, values)
+}
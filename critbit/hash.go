@@ -0,0 +1,262 @@
+package critbit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync/atomic"
+)
+
+// A Merkle digest of a trie or of a subtree within it.
+type Digest [sha256.Size]byte
+
+// Computes the digests of a hashed trie's nodes.
+type hasher[V any] struct {
+	hashValue func(V) ([]byte, error)
+}
+
+// The digests of one version of a hashed trie.  They are kept here rather than on the nodes, so
+// that tries which aren't hashed pay nothing for them, and tries which share nodes but hash them
+// differently can't interfere with each other.
+type hashState[V any] struct {
+	hasher *hasher[V]
+	// the digests of the trie's nodes, once they have been computed.
+	digests atomic.Pointer[digestNode[V]]
+	// the digests of an earlier version of the trie, which are reused for the subtrees that
+	// both versions share.  Dropped once this version's digests are computed.
+	base atomic.Pointer[digestNode[V]]
+}
+
+// The digests of a subtree, in a tree of the same shape as the subtree's nodes.
+type digestNode[V any] struct {
+	node node[V]
+	sum  Digest
+	// the XOR of the digests of every leaf in the subtree.  Unlike the Merkle digest, this can
+	// be combined across subtrees to summarize any range of keys.
+	xor Digest
+	// the digests of an inner node's children.  Nil for a leaf.
+	children [2]*digestNode[V]
+}
+
+/*
+Hashed gets a version of this trie in hashed mode, in which the trie can compute a Merkle digest
+of its contents.  The @hashValue function gives the bytes to hash for each value.  The digests
+are computed the first time they are needed and kept alongside the trie.  A trie derived from
+this one with Set, Delete and the other operations reuses them for the subtrees the two share,
+so after the first call to RootHash, hashing a later version only costs time proportional to
+what changed.  To allow that, a derived trie holds on to the digests of the version it came
+from until its own are computed.
+
+Since a critbit trie's structure depends only on its keys, two tries with the same contents
+always have the same RootHash, no matter what order the keys were inserted in.  ex:
+
+	hashed := tree.Hashed(func(v interface{}) []byte { return []byte(v.(string)) })
+	fmt.Printf("%x", hashed.RootHash())
+*/
+func (t *TypedTrie[V]) Hashed(hashValue func(V) []byte) *TypedTrie[V] {
+	h := &hasher[V]{
+		hashValue: func(v V) ([]byte, error) { return hashValue(v), nil },
+	}
+	return &TypedTrie[V]{root: t.root, hash: &hashState[V]{hasher: h}}
+}
+
+// Gets the Merkle digest of the trie's contents.  The empty trie's digest is all zeros.
+// Panics if the trie is not in hashed mode.
+func (t *TypedTrie[V]) RootHash() Digest {
+	if t.hash == nil {
+		panic("trie is not hashed, see Hashed")
+	}
+	if t.root == nil {
+		return Digest{}
+	}
+	return t.hashedRoot().sum
+}
+
+// One node on the path from the root of a trie down to a key.
+type ProofStep struct {
+	// the node's critical bit
+	Critbyte int
+	Critbit  uint8
+	// the digest of the node's child which is not on the path to the key
+	Sibling Digest
+}
+
+// A Proof that a key-value pair exists in a trie with a certain RootHash.  It holds the path from
+// the root to the key's leaf.
+type Proof []ProofStep
+
+// Gets a proof that the given key exists in the trie, or false if it doesn't exist.
+// Panics if the trie is not in hashed mode.
+func (t *TypedTrie[V]) Proof(key []byte) (Proof, bool) {
+	if t.hash == nil {
+		panic("trie is not hashed, see Hashed")
+	}
+	if t.root == nil {
+		return nil, false
+	}
+
+	proof := make(Proof, 0, 16)
+	d := t.hashedRoot()
+	for in, ok := d.node.(*inner[V]); ok; in, ok = d.node.(*inner[V]) {
+		direction := findDirection(key, in.critbyte, in.critbit)
+		proof = append(proof, ProofStep{
+			Critbyte: in.critbyte,
+			Critbit:  in.critbit,
+			Sibling:  d.children[1-direction].sum,
+		})
+		d = d.children[direction]
+	}
+	if string(d.node.(*leaf[V]).key) != string(key) {
+		return nil, false
+	}
+	return proof, true
+}
+
+// Verifies that the key, whose value hashes to @valueHash, exists in a trie with the given root digest.
+func (p Proof) Verify(root Digest, key []byte, valueHash []byte) bool {
+	sum := leafDigest(key, valueHash)
+	for i := len(p) - 1; i >= 0; i-- {
+		step := p[i]
+		if findDirection(key, step.Critbyte, step.Critbit) == 0 {
+			sum = innerDigest(step.Critbyte, step.Critbit, sum, step.Sibling)
+		} else {
+			sum = innerDigest(step.Critbyte, step.Critbit, step.Sibling, sum)
+		}
+	}
+	return sum == root
+}
+
+//-- internal functions --//
+
+// Gets the digests of a non-empty trie made with Hashed, whose values always hash without error.
+func (t *TypedTrie[V]) hashedRoot() *digestNode[V] {
+	d, _ := t.hash.digestsOf(t.root)
+	return d
+}
+
+// Gets the hash state of a trie derived from the one with this state, which can reuse this
+// trie's digests.  Nil if the trie is not hashed.
+func (h *hashState[V]) derive() *hashState[V] {
+	if h == nil {
+		return nil
+	}
+	ret := &hashState[V]{hasher: h.hasher}
+	if d := h.digests.Load(); d != nil {
+		ret.base.Store(d)
+	} else {
+		//keep only one earlier version, so that a chain of derived tries can't hold on to them all
+		ret.base.Store(h.base.Load())
+	}
+	return ret
+}
+
+// Gets the hasher of this state, which is nil if the trie is not hashed.
+func (h *hashState[V]) getHasher() *hasher[V] {
+	if h == nil {
+		return nil
+	}
+	return h.hasher
+}
+
+// Gets the digests of the trie with the given non-nil root, computing them the first time.
+func (h *hashState[V]) digestsOf(root node[V]) (*digestNode[V], error) {
+	if d := h.digests.Load(); d != nil {
+		return d, nil
+	}
+	d, err := h.hasher.digest(root, h.base.Load())
+	if err != nil {
+		return nil, err
+	}
+	// concurrent readers may race to compute the digests, but they all get the same result.
+	h.digests.Store(d)
+	h.base.Store(nil)
+	return d, nil
+}
+
+// Computes the digests of a subtree, reusing those in @base for any nodes it shares.
+func (h *hasher[V]) digest(n node[V], base *digestNode[V]) (*digestNode[V], error) {
+	if base != nil && base.node == n {
+		return base, nil
+	}
+
+	if l, ok := n.(*leaf[V]); ok {
+		valueHash, err := h.hashValue(l.value)
+		if err != nil {
+			return nil, err
+		}
+		sum := leafDigest(l.key, valueHash)
+		return &digestNode[V]{node: n, sum: sum, xor: sum}, nil
+	}
+
+	in := n.(*inner[V])
+	bases := alignBase(in, base)
+	child0, err := h.digest(in.children[0], bases[0])
+	if err != nil {
+		return nil, err
+	}
+	child1, err := h.digest(in.children[1], bases[1])
+	if err != nil {
+		return nil, err
+	}
+	return &digestNode[V]{
+		node:     n,
+		sum:      innerDigest(in.critbyte, in.critbit, child0.sum, child1.sum),
+		xor:      xorDigest(child0.xor, child1.xor),
+		children: [2]*digestNode[V]{child0, child1},
+	}, nil
+}
+
+// Finds the parts of @base which line up with each of the node's children, since only those can
+// share nodes with them.  Nodes are only reused when they are identical, so a poor match costs
+// time but never gives a wrong digest.
+func alignBase[V any](n *inner[V], base *digestNode[V]) [2]*digestNode[V] {
+	var ret [2]*digestNode[V]
+	for base != nil {
+		//the common case, where a write copied the path down through this node
+		if byteB, bitB := base.node.position(); byteB == n.critbyte && bitB == n.critbit {
+			return base.children
+		}
+
+		o, _, _, dir := findOverlap[V](n, base.node)
+		switch o {
+		case insideB:
+			base = base.children[dir]
+			continue
+		case insideA:
+			ret[dir] = base
+		}
+		break
+	}
+	return ret
+}
+
+// leaves and internal nodes start with a different byte so that one can't be mistaken for the other.
+const (
+	leafDigestPrefix  = 0x00
+	innerDigestPrefix = 0x01
+)
+
+func leafDigest(key []byte, valueHash []byte) Digest {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(key)+len(valueHash))
+	buf = append(buf, leafDigestPrefix)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	buf = append(buf, valueHash...)
+	return sha256.Sum256(buf)
+}
+
+func innerDigest(critbyte int, critbit uint8, child0, child1 Digest) Digest {
+	buf := make([]byte, 0, 2+binary.MaxVarintLen64+2*len(child0))
+	buf = append(buf, innerDigestPrefix)
+	buf = binary.AppendVarint(buf, int64(critbyte))
+	buf = append(buf, critbit)
+	buf = append(buf, child0[:]...)
+	buf = append(buf, child1[:]...)
+	return sha256.Sum256(buf)
+}
+
+func xorDigest(a, b Digest) Digest {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
@@ -0,0 +1,146 @@
+package critbit
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashInt(v interface{}) []byte {
+	return []byte(fmt.Sprint(v))
+}
+
+func TestRootHash_NilTrie(t *testing.T) {
+
+	//act
+	hash := NilTrie().Hashed(hashInt).RootHash()
+
+	//assert
+	assert.Equal(t, Digest{}, hash)
+}
+
+func TestRootHash_NotHashed_Panics(t *testing.T) {
+	assertPanics(t, func() { NilTrie().RootHash() }, "root hash")
+	assertPanics(t, func() { NilTrie().Proof([]byte{0x01}) }, "proof")
+}
+
+func TestRootHash_SameContents_SameHash(t *testing.T) {
+	_, sorted := randomTrie(300)
+	forwards := NilTrie().Hashed(hashInt)
+	backwards := NilTrie().Hashed(hashInt)
+	for i := range sorted {
+		forwards, _ = forwards.Set([]byte(sorted[i]), i)
+		j := len(sorted) - 1 - i
+		backwards, _ = backwards.Set([]byte(sorted[j]), j)
+	}
+
+	//act & assert
+	assert.Equal(t, forwards.RootHash(), backwards.RootHash())
+}
+
+func TestRootHash_DifferentContents_DifferentHash(t *testing.T) {
+	instance, sorted := randomTrie(300)
+	instance = instance.Hashed(hashInt)
+	key := []byte(sorted[rand.Intn(len(sorted))])
+
+	//act
+	before := instance.RootHash()
+	changed, _ := instance.Set(key, -1)
+	deleted, _ := instance.Delete(key)
+
+	//assert
+	assert.NotEqual(t, before, changed.RootHash(), "changed value")
+	assert.NotEqual(t, before, deleted.RootHash(), "deleted key")
+	assert.Equal(t, before, instance.RootHash(), "original should be unchanged")
+}
+
+func TestRootHash_SharesCachedDigests(t *testing.T) {
+	instance, sorted := randomTrie(1000)
+	hashed := 0
+	instance = instance.Hashed(func(v interface{}) []byte {
+		hashed++
+		return hashInt(v)
+	})
+	instance.RootHash()
+	require.Equal(t, len(sorted), hashed)
+
+	//act
+	hashed = 0
+	derived, _ := instance.Set([]byte(sorted[0]), -1)
+	tr := derived.Transient()
+	tr.Set([]byte(sorted[1]), -2)
+	derived = tr.Persistent()
+	derived = Union(derived, NilTrie(), nil)
+	derived.RootHash()
+
+	//assert
+	assert.Equal(t, 2, hashed, "only the changed leaves should be hashed")
+}
+
+func TestProof_VerifiesEveryKey(t *testing.T) {
+	instance, sorted := randomTrie(300)
+	instance = instance.Hashed(hashInt)
+	root := instance.RootHash()
+
+	for _, k := range sorted {
+		value, _ := instance.Get([]byte(k))
+
+		//act
+		proof, ok := instance.Proof([]byte(k))
+
+		//assert
+		require.True(t, ok)
+		assert.True(t, proof.Verify(root, []byte(k), hashInt(value)), "verify %x", k)
+		assert.False(t, proof.Verify(root, []byte(k), hashInt(-1)), "wrong value %x", k)
+	}
+}
+
+func TestProof_MissingKey(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance = instance.Hashed(hashInt)
+
+	//act
+	proof, ok := instance.Proof([]byte("b"))
+
+	//assert
+	assert.False(t, ok)
+	assert.Nil(t, proof)
+}
+
+func TestProof_TamperedSibling_Fails(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+	instance = instance.Hashed(hashInt)
+	proof, _ := instance.Proof([]byte("a"))
+
+	//act
+	proof[0].Sibling[0] ^= 0xFF
+
+	//assert
+	assert.False(t, proof.Verify(instance.RootHash(), []byte("a"), hashInt(1)))
+}
+
+func TestRootHash_SharedNodes_HashedIndependently(t *testing.T) {
+	instance, _ := randomTrie(500)
+	hashDouble := func(v interface{}) []byte {
+		return []byte(fmt.Sprint(v.(int) * 2))
+	}
+	expected := [2]Digest{instance.Hashed(hashInt).RootHash(), instance.Hashed(hashDouble).RootHash()}
+
+	//act
+	var got [2]Digest
+	done := make(chan struct{})
+	go func() {
+		got[1] = instance.Hashed(hashDouble).RootHash()
+		close(done)
+	}()
+	got[0] = instance.Hashed(hashInt).RootHash()
+	<-done
+
+	//assert
+	assert.Equal(t, expected, got)
+	assert.NotEqual(t, got[0], got[1], "different hash functions")
+}
@@ -0,0 +1,160 @@
+package critbit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+/*
+Marshaler pairs a trie with the codec for its values so that it can be saved or sent elsewhere.
+It implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, ex:
+
+	data, err := critbit.Marshaler[interface{}]{Trie: tree, Codec: myCodec}.MarshalBinary()
+
+	m := critbit.Marshaler[interface{}]{Codec: myCodec}
+	err = m.UnmarshalBinary(data)
+	tree = m.Trie
+
+The format is, in order:
+
+	magic       the 4 bytes "CBTR"
+	version     1 byte, currently 1
+	count       uvarint number of entries
+	entries     for each entry in ascending key order:
+	              uvarint key length, key bytes, uvarint value length, encoded value bytes
+	checksum    4 byte big-endian CRC-32 (Castagnoli) of everything before it
+
+Since the entries are sorted, unmarshaling builds the tree bottom-up in linear time.
+*/
+type Marshaler[V any] struct {
+	Trie  *TypedTrie[V]
+	Codec ValueCodec[V]
+}
+
+const (
+	marshalMagic   = "CBTR"
+	marshalVersion = 1
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Encodes the trie in the binary format.
+func (m Marshaler[V]) MarshalBinary() ([]byte, error) {
+	if m.Codec == nil {
+		return nil, errors.New("critbit: marshal: no value codec")
+	}
+	var count uint32
+	if m.Trie != nil {
+		count = m.Trie.Len()
+	}
+
+	buf := make([]byte, 0, 64+16*int(count))
+	buf = append(buf, marshalMagic...)
+	buf = append(buf, marshalVersion)
+	buf = binary.AppendUvarint(buf, uint64(count))
+
+	var err error
+	if count > 0 {
+		m.Trie.VisitAscend(nil, func(key []byte, value V) bool {
+			var data []byte
+			if data, err = m.Codec.EncodeValue(value); err != nil {
+				err = fmt.Errorf("critbit: marshal: encoding value of key %x: %w", key, err)
+				return false
+			}
+			buf = appendBytes(buf, key)
+			buf = appendBytes(buf, data)
+			return true
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint32(buf, crc32.Checksum(buf, crcTable)), nil
+}
+
+// Decodes a trie in the binary format, replacing the Trie field.  The Codec field must be set.
+func (m *Marshaler[V]) UnmarshalBinary(data []byte) error {
+	if m.Codec == nil {
+		return errors.New("critbit: unmarshal: no value codec")
+	}
+	header := len(marshalMagic) + 1
+	if len(data) < header+crc32.Size {
+		return fmt.Errorf("critbit: unmarshal: truncated, only %d bytes", len(data))
+	}
+	if string(data[:len(marshalMagic)]) != marshalMagic {
+		return fmt.Errorf("critbit: unmarshal: bad magic %q", data[:len(marshalMagic)])
+	}
+	if data[len(marshalMagic)] != marshalVersion {
+		return fmt.Errorf("critbit: unmarshal: unsupported version %d", data[len(marshalMagic)])
+	}
+	body, sum := data[:len(data)-crc32.Size], binary.BigEndian.Uint32(data[len(data)-crc32.Size:])
+	if computed := crc32.Checksum(body, crcTable); computed != sum {
+		return fmt.Errorf("critbit: unmarshal: checksum mismatch, stored %08x but computed %08x; the data is truncated or corrupted", sum, computed)
+	}
+
+	// copy the data, since the keys are sliced out of it and the caller may reuse it.
+	body = append([]byte(nil), body...)
+	r := byteReader{data: body, offset: header}
+	count, err := r.uvarint()
+	if err != nil {
+		return fmt.Errorf("critbit: unmarshal: reading entry count: %w", err)
+	}
+	var b sortedBuilder[V]
+	for i := uint64(0); i < count; i++ {
+		key, err := r.bytes()
+		if err != nil {
+			return fmt.Errorf("critbit: unmarshal: entry %d of %d: reading key: %w", i, count, err)
+		}
+		encoded, err := r.bytes()
+		if err != nil {
+			return fmt.Errorf("critbit: unmarshal: entry %d of %d: reading value: %w", i, count, err)
+		}
+		value, err := m.Codec.DecodeValue(encoded)
+		if err != nil {
+			return fmt.Errorf("critbit: unmarshal: entry %d of %d: decoding value of key %x: %w", i, count, key, err)
+		}
+		if err := b.add(key, value); err != nil {
+			return fmt.Errorf("critbit: unmarshal: %w", err)
+		}
+	}
+	if r.offset != len(body) {
+		return fmt.Errorf("critbit: unmarshal: %d unexpected bytes after the last entry", len(body)-r.offset)
+	}
+
+	m.Trie = &TypedTrie[V]{root: b.finish()}
+	return nil
+}
+
+// Reads length-prefixed fields out of a byte slice, reporting the offset of any problem.
+type byteReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *byteReader) uvarint() (uint64, error) {
+	n, size := binary.Uvarint(r.data[r.offset:])
+	switch {
+	case size == 0:
+		return 0, fmt.Errorf("unexpected end of data at offset %d", r.offset)
+	case size < 0:
+		return 0, fmt.Errorf("malformed length at offset %d", r.offset)
+	}
+	r.offset += size
+	return n, nil
+}
+
+func (r *byteReader) bytes() ([]byte, error) {
+	start := r.offset
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.data)-r.offset) {
+		return nil, fmt.Errorf("length %d at offset %d runs past the end of the data", n, start)
+	}
+	ret := r.data[r.offset : r.offset+int(n) : r.offset+int(n)]
+	r.offset += int(n)
+	return ret, nil
+}
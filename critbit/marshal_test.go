@@ -0,0 +1,169 @@
+package critbit
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ encoding.BinaryMarshaler = Marshaler[interface{}]{}
+var _ encoding.BinaryUnmarshaler = &Marshaler[interface{}]{}
+
+// re-signs the body of marshaled data with a correct checksum.
+func resign(data []byte) []byte {
+	body := data[:len(data)-crc32.Size]
+	return binary.BigEndian.AppendUint32(append([]byte(nil), body...), crc32.Checksum(body, crcTable))
+}
+
+func TestMarshalBinary_NilTrie(t *testing.T) {
+
+	//act
+	data, err := Marshaler[interface{}]{Trie: NilTrie(), Codec: intCodec{}}.MarshalBinary()
+
+	//assert
+	require.NoError(t, err)
+	assert.Equal(t, "CBTR\x01\x00", string(data[:len(data)-crc32.Size]))
+	m := Marshaler[interface{}]{Codec: intCodec{}}
+	require.NoError(t, m.UnmarshalBinary(data))
+	assert.Equal(t, 0, m.Trie.Len())
+}
+
+func TestMarshalBinary_Format(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("a"), 10)
+
+	//act
+	data, err := Marshaler[interface{}]{Trie: instance, Codec: intCodec{}}.MarshalBinary()
+
+	//assert
+	require.NoError(t, err)
+	assert.Equal(t, "CBTR\x01\x02\x01a\x0210\x01b\x012", string(data[:len(data)-crc32.Size]))
+	assert.Equal(t, crc32.Checksum(data[:len(data)-crc32.Size], crcTable), binary.BigEndian.Uint32(data[len(data)-crc32.Size:]))
+}
+
+func TestUnmarshalBinary_Random_RoundTrips(t *testing.T) {
+	instance, sorted := randomTrie(2000)
+
+	//act
+	data, err := Marshaler[interface{}]{Trie: instance, Codec: intCodec{}}.MarshalBinary()
+	require.NoError(t, err)
+	m := Marshaler[interface{}]{Codec: intCodec{}}
+	err = m.UnmarshalBinary(data)
+
+	//assert
+	require.NoError(t, err)
+	assert.Equal(t, len(sorted), m.Trie.Len())
+	assert.Equal(t, trieToMap(instance), trieToMap(m.Trie))
+	assertCounts(t, m.Trie.root)
+	assert.Equal(t, instance.Hashed(hashInt).RootHash(), m.Trie.Hashed(hashInt).RootHash(), "same structure")
+}
+
+func TestUnmarshalBinary_DoesNotAliasInput(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("key"), 1)
+	data, _ := Marshaler[interface{}]{Trie: instance, Codec: intCodec{}}.MarshalBinary()
+	m := Marshaler[interface{}]{Codec: intCodec{}}
+	require.NoError(t, m.UnmarshalBinary(data))
+
+	//act
+	for i := range data {
+		data[i] = 0
+	}
+
+	//assert
+	_, ok := m.Trie.Get([]byte("key"))
+	assert.True(t, ok)
+}
+
+func TestUnmarshalBinary_Truncated_Errors(t *testing.T) {
+	instance, _ := randomTrie(20)
+	data, _ := Marshaler[interface{}]{Trie: instance, Codec: intCodec{}}.MarshalBinary()
+
+	for i := 0; i < len(data); i++ {
+		m := Marshaler[interface{}]{Codec: intCodec{}}
+
+		//act
+		err := m.UnmarshalBinary(data[:i])
+
+		//assert
+		assert.Error(t, err, "truncated to %d bytes", i)
+		assert.Nil(t, m.Trie)
+	}
+}
+
+func TestUnmarshalBinary_Corrupted_ChecksumError(t *testing.T) {
+	instance, _ := randomTrie(20)
+	data, _ := Marshaler[interface{}]{Trie: instance, Codec: intCodec{}}.MarshalBinary()
+	data[len(data)/2] ^= 0x10
+
+	//act
+	err := (&Marshaler[interface{}]{Codec: intCodec{}}).UnmarshalBinary(data)
+
+	//assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestUnmarshalBinary_BadHeader_Errors(t *testing.T) {
+	data, _ := Marshaler[interface{}]{Trie: NilTrie(), Codec: intCodec{}}.MarshalBinary()
+
+	//act
+	magic := append([]byte("XXXX"), data[4:]...)
+	version := append([]byte("CBTR\x02"), data[5:]...)
+
+	//assert
+	err := (&Marshaler[interface{}]{Codec: intCodec{}}).UnmarshalBinary(magic)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad magic")
+	err = (&Marshaler[interface{}]{Codec: intCodec{}}).UnmarshalBinary(version)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported version 2")
+}
+
+func TestUnmarshalBinary_Malformed_DescriptiveErrors(t *testing.T) {
+	cases := map[string]string{
+		"CBTR\x01\x02\x01a\x011":           "entry 1 of 2: reading key",
+		"CBTR\x01\x01\x05a\x011":           "runs past the end",
+		"CBTR\x01\x02\x01b\x011\x01a\x012": "not greater than the previous key",
+		"CBTR\x01\x01\x01a\x01x":           "decoding value of key 61",
+		"CBTR\x01\x01\x01a\x011\x00":       "1 unexpected bytes",
+		"CBTR\x01\x80":                     "reading entry count",
+	}
+	for body, expected := range cases {
+		data := resign(append([]byte(body), 0, 0, 0, 0))
+
+		//act
+		err := (&Marshaler[interface{}]{Codec: intCodec{}}).UnmarshalBinary(data)
+
+		//assert
+		if assert.Error(t, err, "%q", body) {
+			assert.Contains(t, err.Error(), expected, "%q", body)
+		}
+	}
+}
+
+func TestMarshalBinary_NoCodec_Errors(t *testing.T) {
+
+	//act
+	_, err := Marshaler[interface{}]{Trie: NilTrie()}.MarshalBinary()
+	err2 := (&Marshaler[interface{}]{}).UnmarshalBinary([]byte("CBTR\x01\x00\x00\x00\x00\x00"))
+
+	//assert
+	assert.Error(t, err)
+	assert.Error(t, err2)
+}
+
+func TestMarshalBinary_EncodeError_Errors(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+
+	//act
+	_, err := Marshaler[interface{}]{Trie: instance, Codec: failingCodec{}}.MarshalBinary()
+
+	//assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errCantEncode))
+}
@@ -0,0 +1,106 @@
+package critbit
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A key which was changed differently on both sides of a Merge3.  Each side's value is given
+// along with whether the key exists on that side.
+type MergeConflict[V any] struct {
+	Key []byte
+
+	Base, Ours, Theirs       V
+	InBase, InOurs, InTheirs bool
+}
+
+/*
+Merge3 reconciles two tries which were both derived from a common @base.  The changes made in
+@theirs are applied to @ours, and the @resolve function is called only for keys which both
+sides changed in different ways.  It returns the value to keep and whether the key should exist
+in the result, or an error which aborts the merge.  If @resolve is nil, the first conflict
+aborts the merge with an error naming its key.
+
+The @eq function decides whether two values are equal.  If it is nil, values are compared with
+== in the same way as Diff, and Merge3 panics if V is not comparable.  Subtrees which are shared
+with @base are skipped, so the merge costs time proportional to the size of the changes, ex:
+
+	merged, err := critbit.Merge3(base, ours, theirs, nil, func(c critbit.MergeConflict[interface{}]) (interface{}, bool, error) {
+		return nil, false, fmt.Errorf("conflicting edits to %s", c.Key)
+	})
+*/
+func Merge3[V any](base, ours, theirs *TypedTrie[V], eq func(V, V) bool, resolve func(MergeConflict[V]) (V, bool, error)) (*TypedTrie[V], error) {
+	eq = defaultEq(eq, "Merge3")
+	switch {
+	case ours.root == base.root || ours.root == theirs.root:
+		return theirs, nil
+	case theirs.root == base.root:
+		return ours, nil
+	}
+
+	same := func(a, b *leaf[V]) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a == b || eq(a.value, b.value)
+	}
+
+	result := ours.Transient()
+	var err error
+	Diff(base, theirs, eq, func(change ChangeType, key []byte, baseValue, theirValue V) bool {
+		baseLeaf, ourLeaf, theirLeaf := getLeaf(base.root, key), getLeaf(ours.root, key), getLeaf(theirs.root, key)
+		if same(ourLeaf, theirLeaf) {
+			//both sides made the same change
+			return true
+		}
+
+		var value V
+		keep := theirLeaf != nil
+		if same(baseLeaf, ourLeaf) {
+			//only theirs changed this key
+			if keep {
+				value = theirLeaf.value
+			}
+		} else {
+			c := MergeConflict[V]{
+				Key:    key,
+				Base:   baseValue,
+				Theirs: theirValue,
+				InBase: baseLeaf != nil, InTheirs: theirLeaf != nil,
+			}
+			if ourLeaf != nil {
+				c.Ours, c.InOurs = ourLeaf.value, true
+			}
+			if resolve == nil {
+				err = fmt.Errorf("critbit: merge: conflicting changes to key %q", key)
+				return false
+			}
+			if value, keep, err = resolve(c); err != nil {
+				return false
+			}
+		}
+
+		if keep {
+			result.Set(key, value)
+		} else {
+			result.Delete(key)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Persistent(), nil
+}
+
+// Gets the leaf for the given key, or nil if it doesn't exist.
+func getLeaf[V any](n node[V], key []byte) *leaf[V] {
+	if n == nil {
+		return nil
+	}
+	best := n.findBestLeaf(key)
+	if !bytes.Equal(key, best.key) {
+		return nil
+	}
+	return best
+}
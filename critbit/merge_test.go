@@ -0,0 +1,178 @@
+package critbit
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge3_OneSideUnchanged_ReturnsOther(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	changed, _ := base.Set([]byte("b"), 2)
+
+	//act
+	ours, err1 := Merge3(base, changed, base, intEq, nil)
+	theirs, err2 := Merge3(base, base, changed, intEq, nil)
+
+	//assert
+	require.Nil(t, err1)
+	require.Nil(t, err2)
+	assert.True(t, ours == changed, "ours")
+	assert.True(t, theirs == changed, "theirs")
+}
+
+func TestMerge3_AppliesBothSides(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	base, _ = base.Set([]byte("b"), 2)
+	base, _ = base.Set([]byte("c"), 3)
+	ours, _ := base.Set([]byte("a"), 11)
+	ours, _ = ours.Delete([]byte("c"))
+	theirs, _ := base.Set([]byte("b"), 22)
+	theirs, _ = theirs.Set([]byte("d"), 4)
+	theirs, _ = theirs.Delete([]byte("c"))
+
+	//act
+	result, err := Merge3(base, ours, theirs, intEq, func(c MergeConflict[interface{}]) (interface{}, bool, error) {
+		assert.Fail(t, "should not conflict", "key %s", c.Key)
+		return nil, false, nil
+	})
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 11, "b": 22, "d": 4}, trieToMap(result))
+	assertCounts(t, result.root)
+	assert.Equal(t, map[string]interface{}{"a": 11, "b": 2}, trieToMap(ours), "ours should remain immutable")
+}
+
+func TestMerge3_Conflict_CallsResolver(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	base, _ = base.Set([]byte("b"), 2)
+	ours, _ := base.Set([]byte("a"), 10)
+	ours, _ = ours.Delete([]byte("b"))
+	theirs, _ := base.Set([]byte("a"), 100)
+	theirs, _ = theirs.Set([]byte("b"), 200)
+
+	//act
+	conflicts := make([]MergeConflict[interface{}], 0)
+	result, err := Merge3(base, ours, theirs, intEq, func(c MergeConflict[interface{}]) (interface{}, bool, error) {
+		conflicts = append(conflicts, c)
+		if string(c.Key) == "a" {
+			return c.Ours.(int) + c.Theirs.(int), true, nil
+		}
+		return nil, false, nil
+	})
+
+	//assert
+	require.Nil(t, err)
+	require.Equal(t, 2, len(conflicts))
+	assert.Equal(t, MergeConflict[interface{}]{Key: []byte("a"), Base: 1, Ours: 10, Theirs: 100, InBase: true, InOurs: true, InTheirs: true}, conflicts[0])
+	assert.Equal(t, MergeConflict[interface{}]{Key: []byte("b"), Base: 2, Ours: nil, Theirs: 200, InBase: true, InOurs: false, InTheirs: true}, conflicts[1])
+	assert.Equal(t, map[string]interface{}{"a": 110}, trieToMap(result))
+}
+
+func TestMerge3_ResolverError_Aborts(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	ours, _ := base.Set([]byte("a"), 10)
+	theirs, _ := base.Set([]byte("a"), 100)
+
+	//act
+	result, err := Merge3(base, ours, theirs, intEq, func(c MergeConflict[interface{}]) (interface{}, bool, error) {
+		return nil, false, errors.New("conflict")
+	})
+
+	//assert
+	assert.Nil(t, result)
+	assert.Equal(t, "conflict", err.Error())
+}
+
+func TestMerge3_NilResolver_ConflictIsError(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	ours, _ := base.Set([]byte("a"), 10)
+	theirs, _ := base.Set([]byte("a"), 100)
+
+	//act
+	result, err := Merge3(base, ours, theirs, nil, nil)
+
+	//assert
+	assert.Nil(t, result)
+	require.NotNil(t, err)
+	assert.Equal(t, `critbit: merge: conflicting changes to key "a"`, err.Error())
+}
+
+func TestMerge3_NilEq_ComparesWithEquals(t *testing.T) {
+	base, _ := NilTrie().Set([]byte("a"), 1)
+	ours, _ := base.Set([]byte("a"), 10)
+	theirs, _ := base.Set([]byte("a"), 10)
+	theirs, _ = theirs.Set([]byte("b"), 2)
+
+	//act
+	result, err := Merge3(base, ours, theirs, nil, nil)
+
+	//assert
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 10, "b": 2}, trieToMap(result))
+}
+
+func TestMerge3_Random_MatchesMaps(t *testing.T) {
+	for round := 0; round < 10; round++ {
+		base, sorted := randomTrie(500)
+		ours, theirs := base, base
+		for i := 0; i < 50; i++ {
+			ours = randomEdit(ours, sorted, i)
+			theirs = randomEdit(theirs, sorted, 1000+i)
+		}
+		baseMap, ourMap, theirMap := trieToMap(base), trieToMap(ours), trieToMap(theirs)
+
+		expected := make(map[string]interface{})
+		conflicts := make(map[string]bool)
+		for _, k := range mergedKeys(ours, theirs) {
+			b, inBase := baseMap[k]
+			o, inOurs := ourMap[k]
+			th, inTheirs := theirMap[k]
+			oursChanged := inOurs != inBase || o != b
+			theirsChanged := inTheirs != inBase || th != b
+			same := inOurs == inTheirs && o == th
+			switch {
+			case same || !theirsChanged:
+				if inOurs {
+					expected[k] = o
+				}
+			case !oursChanged:
+				if inTheirs {
+					expected[k] = th
+				}
+			default:
+				conflicts[k] = true
+				expected[k] = -1
+			}
+		}
+
+		//act
+		result, err := Merge3(base, ours, theirs, intEq, func(c MergeConflict[interface{}]) (interface{}, bool, error) {
+			assert.True(t, conflicts[string(c.Key)], "unexpected conflict on %x", c.Key)
+			delete(conflicts, string(c.Key))
+			return -1, true, nil
+		})
+
+		//assert
+		require.Nil(t, err)
+		assert.Equal(t, 0, len(conflicts), "every conflict should be resolved")
+		require.Equal(t, expected, trieToMap(result))
+		assertCounts(t, result.root)
+	}
+}
+
+func randomEdit(t *Trie, sorted []string, value int) *Trie {
+	switch rand.Intn(3) {
+	case 0:
+		t, _ = t.Delete([]byte(sorted[rand.Intn(len(sorted))]))
+	case 1:
+		t, _ = t.Set([]byte(sorted[rand.Intn(len(sorted))]), value%3)
+	default:
+		t, _ = t.Set([]byte{byte(rand.Intn(4))}, value%3)
+	}
+	return t
+}
@@ -0,0 +1,140 @@
+package critbit
+
+import (
+	"bytes"
+)
+
+// Gets the number of keys in the trie which are less than the given key, in O(depth).
+// The key does not need to exist in the trie.
+func (t *TypedTrie[V]) Rank(key []byte) uint32 {
+	if t.root == nil {
+		return 0
+	}
+	b := findBound(t.root, key)
+
+	var rank uint32
+	n := t.root
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		direction := b.direction(in.critbyte, in.critbit)
+		if direction == 1 {
+			//everything in the 0 child is less than the key
+			rank += in.children[0].size()
+		}
+		n = in.children[direction]
+	}
+	if bytes.Compare(n.(*leaf[V]).key, key) < 0 {
+		rank++
+	}
+	return rank
+}
+
+// Gets the key-value pair at the given index in ascending key order, in O(depth).
+// Returns false if the index is out of range.
+func (t *TypedTrie[V]) At(index uint32) ([]byte, V, bool) {
+	if t.root == nil || index >= t.root.size() {
+		var zero V
+		return nil, zero, false
+	}
+
+	n := t.root
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		if index < in.children[0].size() {
+			n = in.children[0]
+		} else {
+			index -= in.children[0].size()
+			n = in.children[1]
+		}
+	}
+	return leafResult(n.(*leaf[V]))
+}
+
+// Gets the smallest key in the trie and its value.  Returns false if the trie is empty.
+func (t *TypedTrie[V]) Min() ([]byte, V, bool) {
+	if t.root == nil {
+		return leafResult[V](nil)
+	}
+	return leafResult(t.root.edge(0))
+}
+
+// Gets the largest key in the trie and its value.  Returns false if the trie is empty.
+func (t *TypedTrie[V]) Max() ([]byte, V, bool) {
+	if t.root == nil {
+		return leafResult[V](nil)
+	}
+	return leafResult(t.root.edge(1))
+}
+
+// Gets the largest key which is less than or equal to the given key, and its value.
+// Returns false if there is no such key.
+func (t *TypedTrie[V]) Floor(key []byte) ([]byte, V, bool) {
+	return leafResult(findNearest(t.root, key, 0, true))
+}
+
+// Gets the smallest key which is greater than or equal to the given key, and its value.
+// Returns false if there is no such key.
+func (t *TypedTrie[V]) Ceiling(key []byte) ([]byte, V, bool) {
+	return leafResult(findNearest(t.root, key, 1, true))
+}
+
+// Gets the largest key which is strictly less than the given key, and its value.
+// Returns false if there is no such key.
+func (t *TypedTrie[V]) Predecessor(key []byte) ([]byte, V, bool) {
+	return leafResult(findNearest(t.root, key, 0, false))
+}
+
+// Gets the smallest key which is strictly greater than the given key, and its value.
+// Returns false if there is no such key.
+func (t *TypedTrie[V]) Successor(key []byte) ([]byte, V, bool) {
+	return leafResult(findNearest(t.root, key, 1, false))
+}
+
+func leafResult[V any](l *leaf[V]) ([]byte, V, bool) {
+	if l == nil {
+		var zero V
+		return nil, zero, false
+	}
+	return l.key, l.value, true
+}
+
+// Gets the leftmost (0) or rightmost (1) leaf beneath this node.
+func (l *leaf[V]) edge(direction int) *leaf[V] {
+	return l
+}
+
+func (n *inner[V]) edge(direction int) *leaf[V] {
+	child := n.children[direction]
+	for in, ok := child.(*inner[V]); ok; in, ok = child.(*inner[V]) {
+		child = in.children[direction]
+	}
+	return child.(*leaf[V])
+}
+
+// Finds the leaf nearest to the key in the given direction, where 1 finds the smallest key
+// greater than the key and 0 the largest key less than it.  Returns nil if there is none.
+func findNearest[V any](n node[V], key []byte, direction int, inclusive bool) *leaf[V] {
+	if n == nil {
+		return nil
+	}
+	b := findBound(n, key)
+
+	// the closest subtree beside the path, on the side we're looking for.
+	var beside node[V]
+	for in, ok := n.(*inner[V]); ok; in, ok = n.(*inner[V]) {
+		d := b.direction(in.critbyte, in.critbit)
+		if d != direction {
+			beside = in.children[direction]
+		}
+		n = in.children[d]
+	}
+
+	//the leaf we land on is adjacent to the key, but it may be on either side of it.
+	l := n.(*leaf[V])
+	c := bytes.Compare(l.key, key)
+	if (c == 0 && inclusive) || (c > 0 && direction == 1) || (c < 0 && direction == 0) {
+		return l
+	}
+	if beside == nil {
+		return nil
+	}
+	return beside.edge(1 - direction)
+}
@@ -0,0 +1,213 @@
+package critbit
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank_NilTrie(t *testing.T) {
+
+	//act
+	rank := NilTrie().Rank([]byte{0x01})
+
+	//assert
+	assert.Equal(t, 0, rank)
+}
+
+func TestRank_Root(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+
+	//act & assert
+	assert.Equal(t, 0, instance.Rank([]byte{0x01, 0x02, 0x03}), "equal key")
+	assert.Equal(t, 0, instance.Rank([]byte{0x01}), "lesser key")
+	assert.Equal(t, 1, instance.Rank([]byte{0x02}), "greater key")
+}
+
+func TestRank_Deep(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act & assert
+	assert.Equal(t, 0, instance.Rank([]byte("a\x02")))
+	assert.Equal(t, 1, instance.Rank([]byte("bc")))
+	assert.Equal(t, 2, instance.Rank([]byte("bca")))
+	assert.Equal(t, 3, instance.Rank([]byte("e")))
+}
+
+func TestAt_NilTrie(t *testing.T) {
+
+	//act
+	key, val, ok := NilTrie().At(0)
+
+	//assert
+	assert.False(t, ok)
+	assert.Nil(t, key)
+	assert.Nil(t, val)
+}
+
+func TestAt_OutOfRange(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+
+	//act
+	_, _, ok := instance.At(2)
+
+	//assert
+	assert.False(t, ok)
+}
+
+func TestAt_Deep(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	key, val, ok := instance.At(1)
+
+	//assert
+	require.True(t, ok)
+	assert.Equal(t, "bc", string(key))
+	assert.Equal(t, 2, val)
+}
+
+func TestRankAndAt_Random_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i, k := range sorted {
+		//act
+		rank := instance.Rank([]byte(k))
+		key, _, ok := instance.At(uint32(i))
+
+		//assert
+		assert.Equal(t, i, rank, "rank of %x", k)
+		require.True(t, ok)
+		assert.Equal(t, k, string(key))
+	}
+
+	for i := 0; i < 100; i++ {
+		key := randBytes()
+
+		//act
+		rank := instance.Rank(key)
+
+		//assert
+		assert.Equal(t, sort.SearchStrings(sorted, string(key)), rank, "rank of %x", key)
+	}
+}
+
+func TestMinMax_NilTrie(t *testing.T) {
+
+	//act
+	_, _, minOk := NilTrie().Min()
+	_, _, maxOk := NilTrie().Max()
+
+	//assert
+	assert.False(t, minOk)
+	assert.False(t, maxOk)
+}
+
+func TestMinMax_Deep(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	minKey, minVal, minOk := instance.Min()
+	maxKey, maxVal, maxOk := instance.Max()
+
+	//assert
+	require.True(t, minOk)
+	assert.Equal(t, "ba", string(minKey))
+	assert.Equal(t, 1, minVal)
+	require.True(t, maxOk)
+	assert.Equal(t, "d", string(maxKey))
+	assert.Equal(t, 3, maxVal)
+}
+
+func TestNearest_NilTrie(t *testing.T) {
+	instance := NilTrie()
+
+	//act & assert
+	_, _, ok := instance.Floor([]byte{0x01})
+	assert.False(t, ok, "floor")
+	_, _, ok = instance.Ceiling([]byte{0x01})
+	assert.False(t, ok, "ceiling")
+	_, _, ok = instance.Predecessor([]byte{0x01})
+	assert.False(t, ok, "predecessor")
+	_, _, ok = instance.Successor([]byte{0x01})
+	assert.False(t, ok, "successor")
+}
+
+func TestNearest_ExistingKey(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	floor, _, _ := instance.Floor([]byte("bc"))
+	ceiling, _, _ := instance.Ceiling([]byte("bc"))
+	pred, _, _ := instance.Predecessor([]byte("bc"))
+	succ, val, ok := instance.Successor([]byte("bc"))
+
+	//assert
+	assert.Equal(t, "bc", string(floor))
+	assert.Equal(t, "bc", string(ceiling))
+	assert.Equal(t, "ba", string(pred))
+	require.True(t, ok)
+	assert.Equal(t, "d", string(succ))
+	assert.Equal(t, 3, val)
+}
+
+func TestNearest_MissingKey(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	floor, _, floorOk := instance.Floor([]byte("a\x02"))
+	ceiling, _, _ := instance.Ceiling([]byte("a\x02"))
+	pred, _, _ := instance.Predecessor([]byte("bca"))
+	_, _, succOk := instance.Successor([]byte("e"))
+
+	//assert
+	assert.False(t, floorOk, "nothing is less than a")
+	assert.Nil(t, floor)
+	assert.Equal(t, "ba", string(ceiling))
+	assert.Equal(t, "bc", string(pred))
+	assert.False(t, succOk, "nothing is greater than e")
+}
+
+func TestNearest_Random_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i := 0; i < 200; i++ {
+		key := randBytes()
+		if i%2 == 0 {
+			key = []byte(sorted[i%len(sorted)])
+		}
+		// index of the first key >= key, and of the first key > key
+		ge := sort.SearchStrings(sorted, string(key))
+		gt := sort.Search(len(sorted), func(j int) bool { return sorted[j] > string(key) })
+
+		//act & assert
+		assertNearest(t, sorted, gt-1, "floor", key, instance.Floor)
+		assertNearest(t, sorted, ge, "ceiling", key, instance.Ceiling)
+		assertNearest(t, sorted, ge-1, "predecessor", key, instance.Predecessor)
+		assertNearest(t, sorted, gt, "successor", key, instance.Successor)
+	}
+}
+
+func assertNearest(t *testing.T, sorted []string, expected int, name string, key []byte, fn func([]byte) ([]byte, interface{}, bool)) {
+	got, _, ok := fn(key)
+	if expected < 0 || expected >= len(sorted) {
+		assert.False(t, ok, "%s of %x", name, key)
+		return
+	}
+	if assert.True(t, ok, "%s of %x", name, key) {
+		assert.Equal(t, sorted[expected], string(got), "%s of %x", name, key)
+	}
+}
@@ -0,0 +1,156 @@
+package critbit
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Ref is a shared handle to the current version of a trie, which many goroutines can read and
+update without locks.  Readers call Load and get a consistent snapshot which later updates will
+never change.  Writers call Swap with a function computing the next version, which is retried
+if another writer got there first.  Only while a watcher is registered do updates take a lock,
+so that the watchers see them in order, ex:
+
+	ref := critbit.NewRef(critbit.NilTrie())
+	ref.Swap(func(t *critbit.Trie) *critbit.Trie {
+		t, _ = t.SetIfAbsent([]byte("key"), "value")
+		return t
+	})
+	snapshot := ref.Load()
+*/
+type Ref[V any] struct {
+	// the trie and its watchers are swapped together, so an update made without the lock fails
+	// if a watcher registers in the meantime.
+	current atomic.Pointer[refState[V]]
+
+	// held by updates while there are watchers, and by Watch and cancel.
+	mu sync.Mutex
+}
+
+type refState[V any] struct {
+	trie *TypedTrie[V]
+	// never modified, a new slice is made when a watcher is added or removed.
+	watchers []*refWatcher[V]
+}
+
+type refWatcher[V any] struct {
+	fn func(old, new *TypedTrie[V])
+}
+
+// Creates a Ref holding the given trie.  A nil trie is replaced by an empty one.
+func NewRef[V any](t *TypedTrie[V]) *Ref[V] {
+	if t == nil {
+		t = &TypedTrie[V]{}
+	}
+	r := &Ref[V]{}
+	r.current.Store(&refState[V]{trie: t})
+	return r
+}
+
+// Gets the current version of the trie.
+func (r *Ref[V]) Load() *TypedTrie[V] {
+	return r.current.Load().trie
+}
+
+/*
+Swap replaces the trie with the result of @fn, which is given the current version.  If another
+goroutine changed the trie in the meantime, @fn is called again with the newer version until the
+update succeeds, so it should not have side effects.  Returns the version which was stored, which
+is an empty trie if @fn returned nil.  If @fn returns the trie it was given, nothing is stored
+and watchers are not called.
+*/
+func (r *Ref[V]) Swap(fn func(*TypedTrie[V]) *TypedTrie[V]) *TypedTrie[V] {
+	for {
+		old := r.Load()
+		new := fn(old)
+		if new == nil {
+			new = &TypedTrie[V]{}
+		}
+		if new == old || r.CompareAndSet(old, new) {
+			return new
+		}
+	}
+}
+
+// Stores @new only if the current version is @old, compared by pointer.  A nil @new stores an
+// empty trie.  Returns true if it was stored.
+func (r *Ref[V]) CompareAndSet(old, new *TypedTrie[V]) bool {
+	if new == nil {
+		new = &TypedTrie[V]{}
+	}
+	for {
+		state := r.current.Load()
+		if state.trie != old {
+			return false
+		}
+		if len(state.watchers) > 0 {
+			return r.compareAndSetWatched(old, new)
+		}
+		if r.current.CompareAndSwap(state, &refState[V]{trie: new}) {
+			return true
+		}
+		//a watcher was added or removed, try again with the new watchers
+	}
+}
+
+// Stores @new under the lock and reports it to the watchers, so that they see updates in order.
+func (r *Ref[V]) compareAndSetWatched(old, new *TypedTrie[V]) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		state := r.current.Load()
+		if state.trie != old {
+			return false
+		}
+		if !r.current.CompareAndSwap(state, &refState[V]{trie: new, watchers: state.watchers}) {
+			//an update made without the lock got in first
+			continue
+		}
+		for _, w := range state.watchers {
+			w.fn(old, new)
+		}
+		return true
+	}
+}
+
+/*
+Watch registers @fn to be called with the old and new versions after each update, in the order
+the updates happened.  It is called on the goroutine which made the update, before Swap or
+CompareAndSet returns, so it should be quick and must not update or unwatch this Ref itself.
+Returns a function which stops watching.
+*/
+func (r *Ref[V]) Watch(fn func(old, new *TypedTrie[V])) (cancel func()) {
+	w := &refWatcher[V]{fn: fn}
+	r.updateWatchers(func(watchers []*refWatcher[V]) []*refWatcher[V] {
+		return append(watchers[:len(watchers):len(watchers)], w)
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.updateWatchers(func(watchers []*refWatcher[V]) []*refWatcher[V] {
+				ret := make([]*refWatcher[V], 0, len(watchers))
+				for _, other := range watchers {
+					if other != w {
+						ret = append(ret, other)
+					}
+				}
+				return ret
+			})
+		})
+	}
+}
+
+// Replaces the watchers with the result of @fn, keeping the current trie.
+func (r *Ref[V]) updateWatchers(fn func([]*refWatcher[V]) []*refWatcher[V]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		state := r.current.Load()
+		next := &refState[V]{trie: state.trie, watchers: fn(state.watchers)}
+		if r.current.CompareAndSwap(state, next) {
+			return
+		}
+	}
+}
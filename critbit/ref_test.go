@@ -0,0 +1,186 @@
+package critbit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRef_NilTrie_LoadsEmpty(t *testing.T) {
+
+	//act
+	ref := NewRef[interface{}](nil)
+
+	//assert
+	require.NotNil(t, ref.Load())
+	assert.Equal(t, 0, ref.Load().Len())
+}
+
+func TestRef_Swap_ConcurrentIncrements(t *testing.T) {
+	ref := NewRef(NilTrie())
+	var wg sync.WaitGroup
+
+	//act
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ref.Swap(func(t *Trie) *Trie {
+					return t.Alter([]byte("hits"), increment)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	//assert
+	val, _ := ref.Load().Get([]byte("hits"))
+	assert.Equal(t, 2000, val)
+}
+
+func TestRef_Swap_Unchanged_DoesNotStore(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	ref := NewRef(instance)
+	calls := 0
+	ref.Watch(func(old, new *Trie) { calls++ })
+
+	//act
+	result := ref.Swap(func(t *Trie) *Trie {
+		t, _ = t.SetIfAbsent([]byte("a"), 2)
+		return t
+	})
+
+	//assert
+	assert.True(t, instance == result, "should return the unchanged trie")
+	assert.True(t, instance == ref.Load(), "should not store anything")
+	assert.Equal(t, 0, calls)
+}
+
+func TestRef_Swap_Nil_ReturnsStoredTrie(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	ref := NewRef(instance)
+
+	//act
+	result := ref.Swap(func(t *Trie) *Trie { return nil })
+
+	//assert
+	require.NotNil(t, result)
+	assert.True(t, result == ref.Load(), "should return the trie which was stored")
+	assert.Equal(t, 0, result.Len())
+}
+
+func TestRef_CompareAndSet_NoWatchers_DoesNotLock(t *testing.T) {
+	ref := NewRef(NilTrie())
+	second, _ := ref.Load().Set([]byte("a"), 1)
+	ref.mu.Lock()
+	defer ref.mu.Unlock()
+
+	//act
+	done := make(chan bool)
+	go func() { done <- ref.CompareAndSet(ref.Load(), second) }()
+
+	//assert
+	select {
+	case ok := <-done:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "CompareAndSet should not wait for the lock when there are no watchers")
+	}
+}
+
+func TestRef_CompareAndSet(t *testing.T) {
+	first, _ := NilTrie().Set([]byte("a"), 1)
+	second, _ := first.Set([]byte("b"), 2)
+	ref := NewRef(first)
+
+	//act
+	stale := ref.CompareAndSet(second, first)
+	ok := ref.CompareAndSet(first, second)
+
+	//assert
+	assert.False(t, stale)
+	assert.True(t, ok)
+	assert.True(t, second == ref.Load(), "should store the new trie")
+}
+
+func TestRef_Watch_SeesEveryUpdateInOrder(t *testing.T) {
+	ref := NewRef(NilTrie())
+	var updates [][2]*Trie
+	ref.Watch(func(old, new *Trie) {
+		updates = append(updates, [2]*Trie{old, new})
+	})
+	var wg sync.WaitGroup
+
+	//act
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ref.Swap(func(t *Trie) *Trie {
+					return t.Alter([]byte("hits"), increment)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	//assert
+	require.Equal(t, 500, len(updates))
+	for i := 1; i < len(updates); i++ {
+		assert.True(t, updates[i-1][1] == updates[i][0], "update %d should start where the last ended", i)
+	}
+	assert.True(t, ref.Load() == updates[len(updates)-1][1], "the last update should end at the current version")
+}
+
+func TestRef_WatchDuringSwaps_SeesEveryLaterUpdate(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		ref := NewRef(NilTrie())
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					ref.Swap(func(t *Trie) *Trie {
+						return t.Alter([]byte("hits"), increment)
+					})
+				}
+			}()
+		}
+
+		//act
+		var updates [][2]*Trie
+		ref.Watch(func(old, new *Trie) {
+			updates = append(updates, [2]*Trie{old, new})
+		})
+		wg.Wait()
+
+		//assert
+		for i := 1; i < len(updates); i++ {
+			require.True(t, updates[i-1][1] == updates[i][0], "round %d: update %d should start where the last ended", round, i)
+		}
+		if len(updates) > 0 {
+			require.True(t, ref.Load() == updates[len(updates)-1][1], "round %d: the last update should end at the current version", round)
+		}
+	}
+}
+
+func TestRef_Watch_Cancel(t *testing.T) {
+	ref := NewRef(NilTrie())
+	calls := 0
+	cancel := ref.Watch(func(old, new *Trie) { calls++ })
+	ref.Swap(func(t *Trie) *Trie { return t.Alter([]byte("a"), increment) })
+
+	//act
+	cancel()
+	cancel()
+	ref.Swap(func(t *Trie) *Trie { return t.Alter([]byte("a"), increment) })
+
+	//assert
+	assert.Equal(t, 1, calls)
+}
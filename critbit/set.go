@@ -0,0 +1,98 @@
+package critbit
+
+/*
+Set is an immutable set of byte keys, built on the same nodes as a trie.  Its leaves hold an
+empty struct, so no space is used for values.  Like a trie, every change returns a new Set which
+shares structure with the old one, and the comparisons skip over any shared subtrees, ex:
+
+	s := critbit.NilSet().Add([]byte("a")).Add([]byte("b"))
+	s.Contains([]byte("a")) == true
+*/
+type Set struct {
+	root node[struct{}]
+}
+
+var nilSet = &Set{}
+
+// Gets the singleton empty set.
+func NilSet() *Set {
+	return nilSet
+}
+
+func (s *Set) trie() *TypedTrie[struct{}] {
+	return &TypedTrie[struct{}]{root: s.root}
+}
+
+// Gets the number of keys in the set.
+func (s *Set) Len() uint32 {
+	if s.root == nil {
+		return 0
+	}
+	return s.root.size()
+}
+
+// Returns true if the key is in the set.
+func (s *Set) Contains(key []byte) bool {
+	_, ok := s.trie().Get(key)
+	return ok
+}
+
+// Returns a set which includes the key.  If the key is already in the set, this set is returned.
+func (s *Set) Add(key []byte) *Set {
+	t, added := s.trie().SetIfAbsent(key, struct{}{})
+	if !added {
+		return s
+	}
+	return &Set{root: t.root}
+}
+
+// Returns a set without the key.  If the key isn't in the set, this set is returned.
+func (s *Set) Remove(key []byte) *Set {
+	t, _ := s.trie().Delete(key)
+	if t.root == s.root {
+		return s
+	}
+	return &Set{root: t.root}
+}
+
+// Applies the visitor function in ascending order to all keys greater than or equal to @from.
+// The visitor's boolean return value indicates whether to continue.
+func (s *Set) VisitAscend(from []byte, visitor func([]byte) bool) {
+	s.trie().VisitAscend(from, func(key []byte, _ struct{}) bool {
+		return visitor(key)
+	})
+}
+
+// Returns true if every key in this set is also in @other.  Subtrees shared by both sets are
+// not visited.
+func (s *Set) IsSubsetOf(other *Set) bool {
+	return isSubset(s.root, other.root)
+}
+
+// Returns true if both sets contain the same keys.  Subtrees shared by both sets are not visited.
+func (s *Set) Equal(other *Set) bool {
+	return s.Len() == other.Len() && isSubset(s.root, other.root)
+}
+
+func isSubset[V any](a, b node[V]) bool {
+	switch {
+	case a == b || a == nil:
+		return true
+	case b == nil || a.size() > b.size():
+		return false
+	}
+
+	o, _, _, dir := findOverlap(a, b)
+	switch o {
+	case sameKey:
+		return true
+	case aligned:
+		innerA, innerB := a.(*inner[V]), b.(*inner[V])
+		return isSubset(innerA.children[0], innerB.children[0]) && isSubset(innerA.children[1], innerB.children[1])
+	case insideB:
+		return isSubset(a, b.(*inner[V]).children[dir])
+	default:
+		//either they're disjoint, or a has keys on both sides of a critbit where b has keys on one
+		return false
+	}
+}
@@ -0,0 +1,120 @@
+package critbit
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setToSlice(s *Set, from []byte) []string {
+	ret := make([]string, 0, s.Len())
+	s.VisitAscend(from, func(key []byte) bool {
+		ret = append(ret, string(key))
+		return true
+	})
+	return ret
+}
+
+func TestSet_NilSet(t *testing.T) {
+	s := NilSet()
+
+	//act & assert
+	assert.Equal(t, 0, s.Len())
+	assert.False(t, s.Contains([]byte("a")))
+	assert.True(t, s == s.Remove([]byte("a")), "removing a missing key")
+	assert.Empty(t, setToSlice(s, nil))
+}
+
+func TestSet_AddRemoveContains(t *testing.T) {
+
+	//act
+	s := NilSet().Add([]byte("b")).Add([]byte("a")).Add([]byte("c"))
+	removed := s.Remove([]byte("b"))
+
+	//assert
+	assert.Equal(t, 3, s.Len())
+	assert.True(t, s.Contains([]byte("b")))
+	assert.Equal(t, []string{"a", "b", "c"}, setToSlice(s, nil))
+	assert.Equal(t, []string{"b", "c"}, setToSlice(s, []byte("aa")))
+	assert.Equal(t, 2, removed.Len())
+	assert.False(t, removed.Contains([]byte("b")))
+	assert.Equal(t, 0, NilSet().Len(), "nil set should remain immutable")
+}
+
+func TestSet_NoChange_ReturnsSameSet(t *testing.T) {
+	s := NilSet().Add([]byte("a"))
+
+	//act & assert
+	assert.True(t, s == s.Add([]byte("a")), "adding an existing key")
+	assert.True(t, s == s.Remove([]byte("b")), "removing a missing key")
+}
+
+func TestSet_NoValues(t *testing.T) {
+	s := NilSet().Add([]byte("a"))
+
+	//act & assert
+	var setLeaf leaf[struct{}]
+	var trieLeaf leaf[interface{}]
+	assert.True(t, unsafe.Sizeof(setLeaf) < unsafe.Sizeof(trieLeaf), "set leaves should be smaller than trie leaves")
+	assert.Equal(t, struct{}{}, s.root.(*leaf[struct{}]).value)
+}
+
+func TestSet_IsSubsetOf(t *testing.T) {
+	abc := NilSet().Add([]byte("a")).Add([]byte("b")).Add([]byte("c"))
+	ab := abc.Remove([]byte("c"))
+	ad := ab.Remove([]byte("b")).Add([]byte("d"))
+
+	//act & assert
+	assert.True(t, NilSet().IsSubsetOf(abc))
+	assert.True(t, ab.IsSubsetOf(abc))
+	assert.True(t, abc.IsSubsetOf(abc))
+	assert.False(t, abc.IsSubsetOf(ab))
+	assert.False(t, ad.IsSubsetOf(abc))
+	assert.False(t, abc.IsSubsetOf(NilSet()))
+}
+
+func TestSet_Equal(t *testing.T) {
+	forwards := NilSet().Add([]byte("a")).Add([]byte("b")).Add([]byte("c"))
+	backwards := NilSet().Add([]byte("c")).Add([]byte("b")).Add([]byte("a"))
+
+	//act & assert
+	assert.True(t, forwards.Equal(backwards))
+	assert.True(t, NilSet().Equal(&Set{}))
+	assert.False(t, forwards.Equal(forwards.Remove([]byte("a"))))
+	assert.False(t, forwards.Equal(forwards.Remove([]byte("a")).Add([]byte("d"))))
+}
+
+func TestSet_Random_MatchesMap(t *testing.T) {
+	_, sorted := randomTrie(300)
+	for i := 0; i < 50; i++ {
+		a, b := NilSet(), NilSet()
+		inA, inB := make(map[string]bool), make(map[string]bool)
+		for _, k := range sorted {
+			if rand.Intn(2) == 0 {
+				a, inA[k] = a.Add([]byte(k)), true
+			}
+			if rand.Intn(4) != 0 || inA[k] {
+				b, inB[k] = b.Add([]byte(k)), true
+			}
+		}
+		if i%2 == 0 {
+			//share some structure
+			b = a.Add([]byte(sorted[i]))
+			inB = map[string]bool{sorted[i]: true}
+			a.VisitAscend(nil, func(key []byte) bool { inB[string(key)] = true; return true })
+		}
+
+		subset := true
+		for k := range inA {
+			subset = subset && inB[k]
+		}
+
+		//act & assert
+		require.Equal(t, subset, a.IsSubsetOf(b), "round %d", i)
+		require.Equal(t, subset && len(inA) == len(inB), a.Equal(b), "round %d", i)
+		require.Equal(t, len(inA), a.Len())
+	}
+}
@@ -0,0 +1,217 @@
+package critbit
+
+import (
+	"bytes"
+	"math"
+)
+
+/*
+Union gets a trie containing every key in either @a or @b.  When a key exists in both, the
+@resolve function picks the value to keep; if it is nil the value from @b is kept.
+The two tries are walked together, so subtrees which exist on only one side, or which are
+shared between both, are reused without visiting them.  Shared subtrees are kept as-is without
+calling @resolve.
+*/
+func Union[V any](a, b *TypedTrie[V], resolve func(key []byte, a, b V) V) *TypedTrie[V] {
+	return result(a, b, union(a.root, b.root, resolve))
+}
+
+/*
+Intersect gets a trie containing only the keys which exist in both @a and @b.  The @combine
+function gets the value to keep for each key; if it is nil the value from @a is kept.
+Shared subtrees are kept as-is without calling @combine.
+*/
+func Intersect[V any](a, b *TypedTrie[V], combine func(key []byte, a, b V) V) *TypedTrie[V] {
+	return result(a, b, intersect(a.root, b.root, combine))
+}
+
+// Difference gets a trie containing the keys in @a which do not exist in @b.
+func Difference[V any](a, b *TypedTrie[V]) *TypedTrie[V] {
+	return result(a, b, difference(a.root, b.root))
+}
+
+// wraps the root of a set operation, giving back one of the original tries if it didn't change.
+// The result is hashed the same way as @a.
+func result[V any](a, b *TypedTrie[V], root node[V]) *TypedTrie[V] {
+	switch {
+	case root == a.root:
+		return a
+	case root == b.root && a.hash.getHasher() == b.hash.getHasher():
+		return b
+	}
+	return &TypedTrie[V]{root: root, hash: a.hash.derive()}
+}
+
+// describes how two subtrees relate to each other.
+type overlap int
+
+const (
+	// the subtrees have no keys in common, and the critbit says which comes first.
+	disjoint overlap = iota
+	// both subtrees split at the same critbit, so their children can be paired up.
+	aligned
+	// the b subtree fits entirely within one child of a
+	insideA
+	// the a subtree fits entirely within one child of b
+	insideB
+	// both subtrees are leaves with the same key
+	sameKey
+)
+
+// Finds how two non-nil subtrees overlap.  The returned critbit and direction depend on the overlap:
+// for disjoint, the critbit where they differ and the direction a takes there;
+// for insideA or insideB, the critbit of the outer subtree and the direction of the inner one.
+func findOverlap[V any](a, b node[V]) (overlap, int, uint8, int) {
+	// any leaf will do since all of the keys in a subtree are the same above its critical bit.
+	leafA, leafB := a.edge(0), b.edge(0)
+	critbyte, critbit := math.MaxInt, uint8(255)
+	if !bytes.Equal(leafA.key, leafB.key) {
+		critbyte, critbit = findCritbit(leafA.key, leafB.key)
+	} else if a == node[V](leafA) && b == node[V](leafB) {
+		//both subtrees are the leaf itself
+		return sameKey, 0, 0, 0
+	}
+
+	byteA, bitA := a.position()
+	byteB, bitB := b.position()
+	switch {
+	case positionLess(critbyte, critbit, byteA, bitA) && positionLess(critbyte, critbit, byteB, bitB):
+		return disjoint, critbyte, critbit, findDirection(leafA.key, critbyte, critbit)
+	case byteA == byteB && bitA == bitB:
+		return aligned, byteA, bitA, 0
+	case positionLess(byteA, bitA, byteB, bitB):
+		return insideA, byteA, bitA, findDirection(leafB.key, byteA, bitA)
+	default:
+		return insideB, byteB, bitB, findDirection(leafA.key, byteB, bitB)
+	}
+}
+
+// Gets a node joining two subtrees at the given critbit.  Either child may be nil, in which
+// case the other child is returned.  If the children are the same as the template node's,
+// the template is returned instead of a new node.
+func join[V any](template *inner[V], critbyte int, critbit uint8, child0, child1 node[V]) node[V] {
+	if child0 == nil {
+		return child1
+	}
+	if child1 == nil {
+		return child0
+	}
+	if template != nil && template.children[0] == child0 && template.children[1] == child1 {
+		return template
+	}
+	ret := &inner[V]{
+		critbyte: critbyte,
+		critbit:  critbit,
+		count:    child0.size() + child1.size(),
+	}
+	ret.children[0] = child0
+	ret.children[1] = child1
+	return ret
+}
+
+func union[V any](a, b node[V], resolve func([]byte, V, V) V) node[V] {
+	if a == b || b == nil {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+
+	o, critbyte, critbit, dir := findOverlap(a, b)
+	switch o {
+	case sameKey:
+		if resolve == nil {
+			return b
+		}
+		leafA := a.(*leaf[V])
+		return &leaf[V]{
+			key:   leafA.key,
+			value: resolve(leafA.key, leafA.value, b.(*leaf[V]).value),
+		}
+	case disjoint:
+		if dir == 0 {
+			return join(nil, critbyte, critbit, a, b)
+		}
+		return join(nil, critbyte, critbit, b, a)
+	case aligned:
+		innerA, innerB := a.(*inner[V]), b.(*inner[V])
+		return join(innerA, critbyte, critbit,
+			union(innerA.children[0], innerB.children[0], resolve),
+			union(innerA.children[1], innerB.children[1], resolve))
+	case insideA:
+		innerA := a.(*inner[V])
+		if dir == 0 {
+			return join(innerA, critbyte, critbit, union(innerA.children[0], b, resolve), innerA.children[1])
+		}
+		return join(innerA, critbyte, critbit, innerA.children[0], union(innerA.children[1], b, resolve))
+	default:
+		innerB := b.(*inner[V])
+		if dir == 0 {
+			return join(innerB, critbyte, critbit, union(a, innerB.children[0], resolve), innerB.children[1])
+		}
+		return join(innerB, critbyte, critbit, innerB.children[0], union(a, innerB.children[1], resolve))
+	}
+}
+
+func intersect[V any](a, b node[V], combine func([]byte, V, V) V) node[V] {
+	if a == b {
+		return a
+	}
+	if a == nil || b == nil {
+		return nil
+	}
+
+	o, critbyte, critbit, dir := findOverlap(a, b)
+	switch o {
+	case sameKey:
+		if combine == nil {
+			return a
+		}
+		leafA := a.(*leaf[V])
+		return &leaf[V]{
+			key:   leafA.key,
+			value: combine(leafA.key, leafA.value, b.(*leaf[V]).value),
+		}
+	case disjoint:
+		return nil
+	case aligned:
+		innerA, innerB := a.(*inner[V]), b.(*inner[V])
+		return join(innerA, critbyte, critbit,
+			intersect(innerA.children[0], innerB.children[0], combine),
+			intersect(innerA.children[1], innerB.children[1], combine))
+	case insideA:
+		return intersect(a.(*inner[V]).children[dir], b, combine)
+	default:
+		return intersect(a, b.(*inner[V]).children[dir], combine)
+	}
+}
+
+func difference[V any](a, b node[V]) node[V] {
+	if a == b || a == nil {
+		return nil
+	}
+	if b == nil {
+		return a
+	}
+
+	o, critbyte, critbit, dir := findOverlap(a, b)
+	switch o {
+	case sameKey:
+		return nil
+	case disjoint:
+		return a
+	case aligned:
+		innerA, innerB := a.(*inner[V]), b.(*inner[V])
+		return join(innerA, critbyte, critbit,
+			difference(innerA.children[0], innerB.children[0]),
+			difference(innerA.children[1], innerB.children[1]))
+	case insideA:
+		innerA := a.(*inner[V])
+		if dir == 0 {
+			return join(innerA, critbyte, critbit, difference(innerA.children[0], b), innerA.children[1])
+		}
+		return join(innerA, critbyte, critbit, innerA.children[0], difference(innerA.children[1], b))
+	default:
+		return difference(a, b.(*inner[V]).children[dir])
+	}
+}
@@ -0,0 +1,186 @@
+package critbit
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnion_NilTries(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+
+	//act & assert
+	assert.Equal(t, 0, Union(NilTrie(), NilTrie(), nil).Len())
+	assert.True(t, Union(instance, NilTrie(), nil) == instance, "should reuse a")
+	assert.True(t, Union(NilTrie(), instance, nil) == instance, "should reuse b")
+}
+
+func TestUnion_ResolvesConflicts(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("a"), 1)
+	a, _ = a.Set([]byte("b"), 2)
+	b, _ := NilTrie().Set([]byte("b"), 20)
+	b, _ = b.Set([]byte("c"), 30)
+
+	//act
+	result := Union(a, b, func(key []byte, x, y interface{}) interface{} {
+		return x.(int) + y.(int)
+	})
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 22, "c": 30}, trieToMap(result))
+	assertCounts(t, result.root)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, trieToMap(a), "a should remain immutable")
+}
+
+func TestUnion_ReusesSubtreesFromOneSide(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("aa"), 1)
+	a, _ = a.Set([]byte("ab"), 2)
+	b, _ := NilTrie().Set([]byte("ba"), 3)
+	b, _ = b.Set([]byte("bb"), 4)
+
+	//act
+	result := Union(a, b, nil)
+
+	//assert
+	assert.Equal(t, 4, result.Len())
+	root := result.root.(*inner[interface{}])
+	assert.True(t, root.children[0] == a.root, "a should be reused")
+	assert.True(t, root.children[1] == b.root, "b should be reused")
+}
+
+func TestIntersect_CombinesValues(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("a"), 1)
+	a, _ = a.Set([]byte("b"), 2)
+	b, _ := NilTrie().Set([]byte("b"), 20)
+	b, _ = b.Set([]byte("c"), 30)
+
+	//act
+	result := Intersect(a, b, func(key []byte, x, y interface{}) interface{} {
+		return x.(int) + y.(int)
+	})
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"b": 22}, trieToMap(result))
+	assert.Equal(t, 0, Intersect(a, NilTrie(), nil).Len())
+}
+
+func TestIntersect_SameTrie_ReturnsIt(t *testing.T) {
+	instance, _ := randomTrie(100)
+
+	//act
+	result := Intersect(instance, instance, nil)
+
+	//assert
+	assert.True(t, result == instance)
+}
+
+func TestDifference_RemovesKeys(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("a"), 1)
+	a, _ = a.Set([]byte("b"), 2)
+	a, _ = a.Set([]byte("c"), 3)
+	b, _ := NilTrie().Set([]byte("b"), 20)
+	b, _ = b.Set([]byte("d"), 40)
+
+	//act
+	result := Difference(a, b)
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"a": 1, "c": 3}, trieToMap(result))
+	assert.Equal(t, 0, Difference(a, a).Len())
+	assert.True(t, Difference(a, NilTrie()) == a, "should reuse a")
+}
+
+func TestDifference_NothingRemoved_ReturnsOriginal(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("aa"), 1)
+	a, _ = a.Set([]byte("ab"), 2)
+	a, _ = a.Set([]byte("c"), 3)
+	b, _ := NilTrie().Set([]byte("ac"), 4)
+	b, _ = b.Set([]byte("d"), 5)
+
+	//act
+	result := Difference(a, b)
+
+	//assert
+	assert.True(t, result == a)
+}
+
+func TestSetOps_Random_MatchMaps(t *testing.T) {
+	for round := 0; round < 10; round++ {
+		a, sorted := randomTrie(300)
+		b, _ := randomTrie(300)
+		if round%2 == 0 {
+			//derive b from a so they share structure
+			b = a
+			for i := 0; i < 50; i++ {
+				b, _ = b.Delete([]byte(sorted[rand.Intn(len(sorted))]))
+				b, _ = b.Set(randBytes(), 1000+i)
+			}
+		}
+		mapA, mapB := trieToMap(a), trieToMap(b)
+		keep := func(key []byte, x, y interface{}) interface{} { return -x.(int) - y.(int) }
+
+		//act
+		union := Union(a, b, keep)
+		intersect := Intersect(a, b, keep)
+		difference := Difference(a, b)
+
+		//assert
+		expectedUnion, expectedIntersect, expectedDifference := map[string]interface{}{}, map[string]interface{}{}, map[string]interface{}{}
+		for k, v := range mapA {
+			if w, ok := mapB[k]; ok {
+				expectedUnion[k], expectedIntersect[k] = keep(nil, v, w), keep(nil, v, w)
+			} else {
+				expectedUnion[k], expectedDifference[k] = v, v
+			}
+		}
+		for k, v := range mapB {
+			if _, ok := mapA[k]; !ok {
+				expectedUnion[k] = v
+			}
+		}
+		// shared leaves are kept without resolving them
+		sharedUnchanged(a.root, b.root, func(key []byte, v interface{}) {
+			expectedUnion[string(key)], expectedIntersect[string(key)] = v, v
+		})
+
+		require.Equal(t, expectedUnion, trieToMap(union), "union")
+		require.Equal(t, expectedIntersect, trieToMap(intersect), "intersect")
+		require.Equal(t, expectedDifference, trieToMap(difference), "difference")
+		assertCounts(t, union.root)
+		assertCounts(t, intersect.root)
+		assertCounts(t, difference.root)
+		require.Equal(t, mapA, trieToMap(a), "a should remain immutable")
+		require.Equal(t, mapB, trieToMap(b), "b should remain immutable")
+	}
+}
+
+// visits the leaves which are shared between two tries
+func sharedUnchanged[V any](a, b node[V], fn func([]byte, V)) {
+	leaves := make(map[*leaf[V]]bool)
+	var collect func(n node[V])
+	collect = func(n node[V]) {
+		switch n := n.(type) {
+		case *leaf[V]:
+			leaves[n] = true
+		case *inner[V]:
+			collect(n.children[0])
+			collect(n.children[1])
+		}
+	}
+	collect(a)
+	var visit func(n node[V])
+	visit = func(n node[V]) {
+		switch n := n.(type) {
+		case *leaf[V]:
+			if leaves[n] {
+				fn(n.key, n.value)
+			}
+		case *inner[V]:
+			visit(n.children[0])
+			visit(n.children[1])
+		}
+	}
+	visit(b)
+}
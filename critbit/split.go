@@ -0,0 +1,87 @@
+package critbit
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*
+Split cuts the trie at @key, returning a trie of the keys less than @key and a trie of the keys
+greater than or equal to it.  The key does not need to exist in the trie.  Only the nodes on the
+path to the key are rebuilt, so this takes O(depth) and both halves share the rest of their
+structure with the original, ex:
+
+	lower, upper := shards.Split([]byte("m"))
+*/
+func (t *TypedTrie[V]) Split(key []byte) (*TypedTrie[V], *TypedTrie[V]) {
+	if t.root == nil {
+		return t, t
+	}
+	lower, upper := t.root.split(findBound(t.root, key))
+	return t.withRoot(lower), t.withRoot(upper)
+}
+
+/*
+Join gets a trie containing the keys of both @a and @b, where every key in @a must be less than
+every key in @b.  Returns an error if their keys overlap.  Only the right edge of @a and the left
+edge of @b are visited, so this takes O(depth).  The result is hashed the same way as @a.
+*/
+func Join[V any](a, b *TypedTrie[V]) (*TypedTrie[V], error) {
+	if a.root == nil || b.root == nil {
+		return result(a, b, union(a.root, b.root, nil)), nil
+	}
+	last, first := a.root.edge(1).key, b.root.edge(0).key
+	if bytes.Compare(last, first) >= 0 {
+		return nil, fmt.Errorf("critbit: cannot join overlapping tries, the first ends at %x but the second starts at %x", last, first)
+	}
+	critbyte, critbit := findCritbit(last, first)
+	return result(a, b, joinEdges(a.root, b.root, critbyte, critbit)), nil
+}
+
+// Joins two subtrees where every key in @a is less than every key in @b.  The critbit is where
+// the last key of @a differs from the first key of @b, which stays the same all the way down
+// since only the right edge of @a and the left edge of @b are followed.
+func joinEdges[V any](a, b node[V], critbyte int, critbit uint8) node[V] {
+	byteA, bitA := a.position()
+	byteB, bitB := b.position()
+	switch {
+	case positionLess(byteA, bitA, critbyte, critbit) && positionLess(byteA, bitA, byteB, bitB):
+		// all of @b goes the same way as the last key of @a.
+		in := a.(*inner[V])
+		return join(in, byteA, bitA, in.children[0], joinEdges(in.children[1], b, critbyte, critbit))
+	case positionLess(byteB, bitB, critbyte, critbit):
+		// all of @a goes the same way as the first key of @b.
+		in := b.(*inner[V])
+		return join(in, byteB, bitB, joinEdges(a, in.children[0], critbyte, critbit), in.children[1])
+	}
+	// the tries first differ at the critbit, so they sit side by side below a new node.
+	return join(nil, critbyte, critbit, a, b)
+}
+
+// Gets a trie with the given root and the same hashing as this one, or this trie if the root
+// is the same.
+func (t *TypedTrie[V]) withRoot(root node[V]) *TypedTrie[V] {
+	if root == t.root {
+		return t
+	}
+	return &TypedTrie[V]{root: root, hash: t.hash.derive()}
+}
+
+// Splits the subtree into the keys less than the bound and the keys greater than or equal to it.
+func (l *leaf[V]) split(b *bound) (node[V], node[V]) {
+	if bytes.Compare(l.key, b.key) < 0 {
+		return l, nil
+	}
+	return nil, l
+}
+
+func (n *inner[V]) split(b *bound) (node[V], node[V]) {
+	if b.direction(n.critbyte, n.critbit) == 0 {
+		//the whole 1 child is greater than the bound
+		lower, upper := n.children[0].split(b)
+		return lower, join(n, n.critbyte, n.critbit, upper, n.children[1])
+	}
+	//the whole 0 child is less than the bound
+	lower, upper := n.children[1].split(b)
+	return join(n, n.critbyte, n.critbit, n.children[0], lower), upper
+}
@@ -0,0 +1,182 @@
+package critbit
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counts the nodes in @n which don't appear in any of the given tries.
+func countNewNodes[V any](n node[V], originals ...node[V]) int {
+	seen := make(map[node[V]]bool)
+	var collect func(n node[V])
+	collect = func(n node[V]) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		if in, ok := n.(*inner[V]); ok {
+			collect(in.children[0])
+			collect(in.children[1])
+		}
+	}
+	for _, o := range originals {
+		collect(o)
+	}
+	var count func(n node[V]) int
+	count = func(n node[V]) int {
+		if n == nil || seen[n] {
+			return 0
+		}
+		if in, ok := n.(*inner[V]); ok {
+			return 1 + count(in.children[0]) + count(in.children[1])
+		}
+		return 1
+	}
+	return count(n)
+}
+
+func TestSplit_NilTrie(t *testing.T) {
+
+	//act
+	lower, upper := NilTrie().Split([]byte("a"))
+
+	//assert
+	assert.Equal(t, 0, lower.Len())
+	assert.Equal(t, 0, upper.Len())
+}
+
+func TestSplit_ExistingKey_GoesUpper(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+	instance, _ = instance.Set([]byte("d"), 3)
+
+	//act
+	lower, upper := instance.Split([]byte("bc"))
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"ba": 1}, trieToMap(lower))
+	assert.Equal(t, map[string]interface{}{"bc": 2, "d": 3}, trieToMap(upper))
+	assert.Equal(t, 3, instance.Len(), "original should remain immutable")
+}
+
+func TestSplit_OutsideRange_ReusesTrie(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("b"), 1)
+	instance, _ = instance.Set([]byte("c"), 2)
+
+	//act
+	lower, upper := instance.Split([]byte("a"))
+	lower2, upper2 := instance.Split([]byte("d"))
+
+	//assert
+	assert.Equal(t, 0, lower.Len())
+	assert.True(t, instance == upper, "upper")
+	assert.True(t, instance == lower2, "lower2")
+	assert.Equal(t, 0, upper2.Len())
+}
+
+func TestSplit_Random_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(2000)
+
+	for i := 0; i < 100; i++ {
+		key := randBytes()
+		if i%2 == 0 {
+			key = []byte(sorted[rand.Intn(len(sorted))])
+		}
+		at := sort.SearchStrings(sorted, string(key))
+
+		//act
+		lower, upper := instance.Split(key)
+
+		//assert
+		require.Equal(t, at, lower.Len(), "split at %x", key)
+		require.Equal(t, len(sorted)-at, upper.Len(), "split at %x", key)
+		if at > 0 {
+			last, _, _ := lower.Max()
+			assert.Equal(t, sorted[at-1], string(last))
+		}
+		if at < len(sorted) {
+			first, _, _ := upper.Min()
+			assert.Equal(t, sorted[at], string(first))
+		}
+		assertCounts(t, lower.root)
+		assertCounts(t, upper.root)
+		assert.True(t, countNewNodes(lower.root, instance.root) < 40, "lower should reuse the original's nodes")
+		assert.True(t, countNewNodes(upper.root, instance.root) < 40, "upper should reuse the original's nodes")
+	}
+}
+
+func TestJoin_Overlapping_Errors(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("a"), 1)
+	a, _ = a.Set([]byte("c"), 2)
+	b, _ := NilTrie().Set([]byte("b"), 3)
+
+	//act
+	_, err := Join(a, b)
+	_, sameKey := Join(a, a)
+
+	//assert
+	assert.Error(t, err)
+	assert.Error(t, sameKey)
+}
+
+func TestJoin_Empty_ReusesOther(t *testing.T) {
+	a, _ := NilTrie().Set([]byte("a"), 1)
+
+	//act
+	left, err := Join(a, NilTrie())
+	require.NoError(t, err)
+	right, err := Join(NilTrie(), a)
+	require.NoError(t, err)
+
+	//assert
+	assert.True(t, a == left, "left")
+	assert.True(t, a == right, "right")
+}
+
+func TestJoin_SplitHalves_RebuildsOriginal(t *testing.T) {
+	instance, sorted := randomTrie(2000)
+	instance = instance.Hashed(hashInt)
+
+	for i := 0; i < 50; i++ {
+		lower, upper := instance.Split(randBytes())
+
+		//act
+		joined, err := Join(lower, upper)
+
+		//assert
+		require.NoError(t, err)
+		require.Equal(t, len(sorted), joined.Len())
+		assertCounts(t, joined.root)
+		assert.Equal(t, instance.RootHash(), joined.RootHash(), "same keys give the same structure")
+		assert.True(t, countNewNodes(joined.root, lower.root, upper.root) < 40, "join should reuse both halves")
+	}
+}
+
+func TestJoin_PrefixKeys_MatchesSet(t *testing.T) {
+	keys := [][]byte{[]byte("a"), []byte("a\x00"), []byte("a\x00\x00"), []byte("a\x01"), []byte("b")}
+	expected := NilTrie()
+	for i, k := range keys {
+		expected, _ = expected.Set(k, i)
+	}
+	expected = expected.Hashed(hashInt)
+
+	for i := 0; i <= len(keys); i++ {
+		lower, upper := expected.Split(keys[min(i, len(keys)-1)])
+		if i == len(keys) {
+			lower, upper = expected, NilTrie()
+		}
+
+		//act
+		joined, err := Join(lower, upper)
+
+		//assert
+		require.NoError(t, err)
+		assertCounts(t, joined.root)
+		assert.Equal(t, expected.Len(), joined.Len())
+		assert.Equal(t, expected.RootHash(), joined.RootHash(), "same keys give the same structure")
+	}
+}
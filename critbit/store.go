@@ -0,0 +1,708 @@
+package critbit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+/*
+Store is a trie kept in an append-only file, which extends the copy-on-write model of the trie
+to disk.  Changes are made in memory with Set and Delete, and Commit appends only the new nodes
+on the changed paths to the file, sharing every unchanged subtree with the earlier versions.
+Nodes are read from the file lazily, the first time a lookup passes through them, and are
+cached afterwards.
+
+Each commit gets a version number, and any committed version can be reopened as a read-only
+Snapshot.  The file starts with two header slots which commits alternate between, and a header
+is only written after the data it points to has been synced.  So if the process crashes
+mid-commit, opening the store again recovers the last commit whose header is intact.
+
+A Store and its snapshots must not be used from multiple goroutines at once.  ex:
+
+	store, err := critbit.OpenStore[interface{}]("data.cbst", myCodec)
+	_, err = store.Set([]byte("key"), "value")
+	version, err := store.Commit()
+	...
+	snap, err := store.Snapshot(version)
+	value, ok, err := snap.Get([]byte("key"))
+*/
+type Store[V any] struct {
+	f *storeFile[V]
+	// the root of the working trie, including changes which aren't committed yet.
+	root  *diskNode[V]
+	count uint32
+	// the latest commit, which a Rollback returns to.
+	header    storeHeader
+	committed storeCommit
+	root0     *diskNode[V]
+}
+
+// A read-only view of a version of a Store.
+type Snapshot[V any] struct {
+	f       *storeFile[V]
+	root    *diskNode[V]
+	version uint64
+	count   uint32
+}
+
+// A node of a trie in a store.  Nodes from the file start out holding only their offset, and
+// the rest is read from the file the first time it's needed.
+type diskNode[V any] struct {
+	// where the node is in the file, or 0 if it hasn't been written yet.
+	offset int64
+	loaded bool
+	// whether this is a leaf holding a key and value, rather than an inner node.  Only known
+	// once the node is loaded.
+	isLeaf bool
+
+	critbyte int
+	critbit  uint8
+	count    uint32
+	children [2]*diskNode[V]
+
+	key   []byte
+	value V
+}
+
+/*
+The file layout is two header slots, followed by records.  Each header slot holds:
+
+	magic       the 4 bytes "CBST"
+	format      1 byte, currently 1, then 3 bytes of padding
+	version     8 byte big-endian number of the latest commit
+	commit      8 byte offset of the latest commit record, or 0 if nothing is committed
+	end         8 byte length of the file covered by the header
+	checksum    4 byte big-endian CRC-32 (Castagnoli) of the slot up to here
+
+Each record is a 4 byte big-endian length and a 4 byte big-endian CRC-32 (Castagnoli) of its
+contents, followed by the contents: a type byte and the fields below, where numbers are uvarints
+unless noted and byte strings are prefixed with their uvarint length:
+
+	leaf        key, encoded value
+	inner       critbyte as a signed varint, critbit byte, count, child offsets 0 and 1
+	commit      version, root offset (or 0 if empty), count, offset of the previous commit (or 0)
+
+Records only ever point back to records written before them, so every offset in a record is
+less than the record's own offset.
+*/
+const (
+	storeMagic     = "CBST"
+	storeFormat    = 1
+	storeSlotSize  = 64
+	storeDataStart = 2 * storeSlotSize
+	// the size of a header slot's contents before the checksum.
+	storeHeaderSize = 32
+	// the size of a record's length and checksum.
+	storeRecordHeaderSize = 8
+	// the largest record that will be read, so a corrupt length can't exhaust memory.
+	maxStoreRecord = 1 << 28
+)
+
+const (
+	storeLeaf byte = iota
+	storeInner
+	storeCommitRecord
+)
+
+type storeHeader struct {
+	version uint64
+	commit  int64
+	end     int64
+}
+
+type storeCommit struct {
+	version uint64
+	root    int64
+	count   uint32
+	prev    int64
+}
+
+type storeFile[V any] struct {
+	file  *os.File
+	codec ValueCodec[V]
+}
+
+// Opens the store in the file at @path, creating it if it doesn't exist.  If the last commit
+// was interrupted, the store is recovered to the commit before it.
+func OpenStore[V any](path string, codec ValueCodec[V]) (*Store[V], error) {
+	if codec == nil {
+		return nil, errors.New("critbit store: no value codec")
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store[V]{f: &storeFile[V]{file: file, codec: codec}}
+	if err := s.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reads the newest valid header, and discards anything written after it.
+func (s *Store[V]) recover() error {
+	info, err := s.f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("critbit store: %w", err)
+	}
+	if info.Size() == 0 {
+		//a new store
+		if err := s.f.file.Truncate(storeDataStart); err != nil {
+			return fmt.Errorf("critbit store: %w", err)
+		}
+		s.header = storeHeader{end: storeDataStart}
+		return s.writeHeader()
+	}
+
+	var found bool
+	for slot := 0; slot < 2; slot++ {
+		h, ok := s.f.readHeader(slot)
+		if ok && h.end <= info.Size() && (!found || h.version > s.header.version) {
+			s.header, found = h, true
+		}
+	}
+	if !found {
+		return errors.New("critbit store: no valid header, the file is not a store or is corrupted")
+	}
+	if info.Size() > s.header.end {
+		//a commit was interrupted before its header was written
+		if err := s.f.file.Truncate(s.header.end); err != nil {
+			return fmt.Errorf("critbit store: discarding interrupted commit: %w", err)
+		}
+	}
+
+	if s.header.commit != 0 {
+		if s.committed, err = s.f.readCommit(s.header.commit); err != nil {
+			return err
+		}
+	}
+	s.root0 = stubNode[V](s.committed.root)
+	s.Rollback()
+	return nil
+}
+
+// Closes the file.  Uncommitted changes are lost.
+func (s *Store[V]) Close() error {
+	return s.f.file.Close()
+}
+
+// Gets the version of the latest commit, which is 0 if nothing has been committed.
+func (s *Store[V]) Version() uint64 {
+	return s.header.version
+}
+
+// Gets the number of items in the store, including uncommitted changes.
+func (s *Store[V]) Len() uint32 {
+	return s.count
+}
+
+// Gets an item by its key, including uncommitted changes.  Returns the item and a boolean which
+// is true if the item existed.
+func (s *Store[V]) Get(key []byte) (V, bool, error) {
+	return s.f.get(s.root, key)
+}
+
+// Applies the visitor function in ascending order to all key-value pairs whose keys are
+// greater than or equal to @from, including uncommitted changes.  The visitor's boolean return
+// value indicates whether to continue.
+func (s *Store[V]) VisitAscend(from []byte, visitor func([]byte, V) bool) error {
+	return s.f.visitAscend(s.root, from, visitor)
+}
+
+// Sets the given key to the given value, returning the previous value if there was one.  The
+// change is not saved until Commit is called.
+func (s *Store[V]) Set(key []byte, value V) (V, error) {
+	var zero V
+	if any(value) == nil {
+		panic("value cannot be nil")
+	}
+	leaf := &diskNode[V]{loaded: true, isLeaf: true, key: key, value: value, count: 1}
+	if s.root == nil {
+		s.root, s.count = leaf, 1
+		return zero, nil
+	}
+
+	best, err := s.f.findBestLeaf(s.root, key)
+	if err != nil {
+		return zero, err
+	}
+	if bytes.Equal(key, best.key) {
+		s.root = s.root.setLeaf(leaf)
+		return best.value, nil
+	}
+	critbyte, critbit := findCritbit(key, best.key)
+	s.root = s.root.insertLeaf(leaf, critbyte, critbit)
+	s.count++
+	return zero, nil
+}
+
+// Deletes the given key, returning its value if it existed.  The change is not saved until
+// Commit is called.
+func (s *Store[V]) Delete(key []byte) (V, error) {
+	var zero V
+	if s.root == nil {
+		return zero, nil
+	}
+	best, err := s.f.findBestLeaf(s.root, key)
+	if err != nil || !bytes.Equal(key, best.key) {
+		return zero, err
+	}
+	s.root = s.root.deleteLeaf(key)
+	s.count--
+	return best.value, nil
+}
+
+// Discards any changes made since the last commit.
+func (s *Store[V]) Rollback() {
+	s.root, s.count = s.root0, s.committed.count
+}
+
+/*
+Commit appends the changes since the last commit to the file and makes them durable, returning
+the new version number.  If nothing changed, no commit is written and the current version is
+returned.
+*/
+func (s *Store[V]) Commit() (uint64, error) {
+	if s.root == s.root0 {
+		return s.header.version, nil
+	}
+
+	// offsets are given to the new nodes as they're encoded, and taken back if the commit fails.
+	var written []*diskNode[V]
+	buf, err := s.f.appendNodes(nil, s.root, s.header.end, &written)
+	if err == nil {
+		commit := storeCommit{
+			version: s.header.version + 1,
+			count:   s.count,
+			prev:    s.header.commit,
+		}
+		if s.root != nil {
+			commit.root = s.root.offset
+		}
+		header := storeHeader{
+			version: commit.version,
+			commit:  s.header.end + int64(len(buf)),
+		}
+		buf = appendCommit(buf, commit)
+		header.end = s.header.end + int64(len(buf))
+
+		if err = s.f.writeAndSync(buf, s.header.end); err == nil {
+			old := s.header
+			s.header = header
+			if err = s.writeHeader(); err != nil {
+				s.header = old
+			} else {
+				s.committed, s.root0 = commit, s.root
+				return commit.version, nil
+			}
+		}
+	}
+
+	for _, n := range written {
+		n.offset = 0
+	}
+	return 0, err
+}
+
+// Writes the header to the slot for its version, and syncs it.
+func (s *Store[V]) writeHeader() error {
+	slot := make([]byte, 0, storeSlotSize)
+	slot = append(slot, storeMagic...)
+	slot = append(slot, storeFormat, 0, 0, 0)
+	slot = binary.BigEndian.AppendUint64(slot, s.header.version)
+	slot = binary.BigEndian.AppendUint64(slot, uint64(s.header.commit))
+	slot = binary.BigEndian.AppendUint64(slot, uint64(s.header.end))
+	slot = binary.BigEndian.AppendUint32(slot, crc32.Checksum(slot, crcTable))
+	if err := s.f.writeAndSync(slot, int64(s.header.version%2)*storeSlotSize); err != nil {
+		return fmt.Errorf("critbit store: writing header: %w", err)
+	}
+	return nil
+}
+
+// Gets the versions which have been committed, in ascending order.
+func (s *Store[V]) Versions() ([]uint64, error) {
+	var ret []uint64
+	for offset := s.header.commit; offset != 0; {
+		c, err := s.f.readCommit(offset)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c.version)
+		offset = c.prev
+	}
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret, nil
+}
+
+// Opens a read-only snapshot of the store as of the given committed version.
+func (s *Store[V]) Snapshot(version uint64) (*Snapshot[V], error) {
+	if version == 0 || version > s.header.version {
+		return nil, fmt.Errorf("critbit store: version %d has not been committed", version)
+	}
+	for offset := s.header.commit; offset != 0; {
+		c, err := s.f.readCommit(offset)
+		if err != nil {
+			return nil, err
+		}
+		if c.version == version {
+			return &Snapshot[V]{f: s.f, root: stubNode[V](c.root), version: version, count: c.count}, nil
+		}
+		offset = c.prev
+	}
+	return nil, fmt.Errorf("critbit store: version %d not found", version)
+}
+
+// Gets the version of the store which this snapshot shows.
+func (s *Snapshot[V]) Version() uint64 {
+	return s.version
+}
+
+// Gets the number of items in the snapshot.
+func (s *Snapshot[V]) Len() uint32 {
+	return s.count
+}
+
+// Gets an item by its key.  Returns the item and a boolean which is true if the item existed.
+func (s *Snapshot[V]) Get(key []byte) (V, bool, error) {
+	return s.f.get(s.root, key)
+}
+
+// Applies the visitor function in ascending order to all key-value pairs whose keys are
+// greater than or equal to @from.  The visitor's boolean return value indicates whether to continue.
+func (s *Snapshot[V]) VisitAscend(from []byte, visitor func([]byte, V) bool) error {
+	return s.f.visitAscend(s.root, from, visitor)
+}
+
+//-- reading --//
+
+// Gets a node which will be read from the given offset when it's needed, or nil for offset 0.
+func stubNode[V any](offset int64) *diskNode[V] {
+	if offset == 0 {
+		return nil
+	}
+	return &diskNode[V]{offset: offset}
+}
+
+func (f *storeFile[V]) get(root *diskNode[V], key []byte) (V, bool, error) {
+	var zero V
+	if root == nil {
+		return zero, false, nil
+	}
+	leaf, err := f.findBestLeaf(root, key)
+	if err != nil || !bytes.Equal(key, leaf.key) {
+		return zero, false, err
+	}
+	return leaf.value, true, nil
+}
+
+// Finds the leaf the key would be at, loading each node along the way.
+func (f *storeFile[V]) findBestLeaf(n *diskNode[V], key []byte) (*diskNode[V], error) {
+	for {
+		if err := f.load(n); err != nil {
+			return nil, err
+		}
+		if n.isLeaf {
+			return n, nil
+		}
+		n = n.children[findDirection(key, n.critbyte, n.critbit)]
+	}
+}
+
+func (f *storeFile[V]) visitAscend(root *diskNode[V], from []byte, visitor func([]byte, V) bool) error {
+	if root == nil {
+		return nil
+	}
+	var b *bound
+	if from != nil {
+		leaf, err := f.findBestLeaf(root, from)
+		if err != nil {
+			return err
+		}
+		b = &bound{key: from, dir: -1}
+		if !bytes.Equal(from, leaf.key) {
+			b.critbyte, b.critbit = findCritbit(from, leaf.key)
+			b.dir = findDirection(from, b.critbyte, b.critbit)
+		}
+	}
+	_, err := f.visit(root, b, visitor)
+	return err
+}
+
+// Visits the subtree in ascending order, skipping keys less than the bound if it's not nil.
+// Returns false if the visitor stopped.
+func (f *storeFile[V]) visit(n *diskNode[V], from *bound, visitor func([]byte, V) bool) (bool, error) {
+	if err := f.load(n); err != nil {
+		return false, err
+	}
+	if n.isLeaf {
+		if from != nil && bytes.Compare(n.key, from.key) < 0 {
+			return true, nil
+		}
+		return visitor(n.key, n.value), nil
+	}
+
+	if from != nil && from.direction(n.critbyte, n.critbit) == 1 {
+		//the whole 0 child is less than the bound
+		return f.visit(n.children[1], from, visitor)
+	}
+	if ok, err := f.visit(n.children[0], from, visitor); !ok || err != nil {
+		return ok, err
+	}
+	//everything in the 1 child is greater than the bound
+	return f.visit(n.children[1], nil, visitor)
+}
+
+// Reads the node from the file if it hasn't been read yet.
+func (f *storeFile[V]) load(n *diskNode[V]) error {
+	if n.loaded {
+		return nil
+	}
+	kind, r, err := f.readRecord(n.offset)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case storeLeaf:
+		key, err := r.bytes()
+		if err != nil {
+			return fmt.Errorf("critbit store: node at offset %d: reading key: %w", n.offset, err)
+		}
+		encoded, err := r.bytes()
+		if err != nil {
+			return fmt.Errorf("critbit store: node at offset %d: reading value: %w", n.offset, err)
+		}
+		value, err := f.codec.DecodeValue(encoded)
+		if err != nil {
+			return fmt.Errorf("critbit store: node at offset %d: decoding value of key %x: %w", n.offset, key, err)
+		}
+		n.isLeaf, n.key, n.value, n.count = true, key, value, 1
+
+	case storeInner:
+		critbyte, size := binary.Varint(r.data[r.offset:])
+		if size <= 0 || r.offset+size >= len(r.data) {
+			return fmt.Errorf("critbit store: node at offset %d: malformed critbit", n.offset)
+		}
+		r.offset += size
+		critbit := r.data[r.offset]
+		r.offset++
+		var fields [3]uint64
+		for i := range fields {
+			if fields[i], err = r.uvarint(); err != nil {
+				return fmt.Errorf("critbit store: node at offset %d: %w", n.offset, err)
+			}
+		}
+		for _, child := range fields[1:] {
+			if err := checkStoreOffset(child, n.offset); err != nil {
+				return fmt.Errorf("critbit store: node at offset %d: child %w", n.offset, err)
+			}
+		}
+		n.critbyte, n.critbit, n.count = int(critbyte), critbit, uint32(fields[0])
+		n.children[0] = stubNode[V](int64(fields[1]))
+		n.children[1] = stubNode[V](int64(fields[2]))
+
+	default:
+		return fmt.Errorf("critbit store: expected a node at offset %d but found record type %d", n.offset, kind)
+	}
+	n.loaded = true
+	return nil
+}
+
+func (f *storeFile[V]) readCommit(offset int64) (storeCommit, error) {
+	kind, r, err := f.readRecord(offset)
+	if err != nil {
+		return storeCommit{}, err
+	}
+	if kind != storeCommitRecord {
+		return storeCommit{}, fmt.Errorf("critbit store: expected a commit at offset %d but found record type %d", offset, kind)
+	}
+	var fields [4]uint64
+	for i := range fields {
+		if fields[i], err = r.uvarint(); err != nil {
+			return storeCommit{}, fmt.Errorf("critbit store: commit at offset %d: %w", offset, err)
+		}
+	}
+	// the root and the previous commit are optional, but must come before the commit if set.
+	for _, ref := range []uint64{fields[1], fields[3]} {
+		if ref == 0 {
+			continue
+		}
+		if err := checkStoreOffset(ref, offset); err != nil {
+			return storeCommit{}, fmt.Errorf("critbit store: commit at offset %d: %w", offset, err)
+		}
+	}
+	return storeCommit{
+		version: fields[0],
+		root:    int64(fields[1]),
+		count:   uint32(fields[2]),
+		prev:    int64(fields[3]),
+	}, nil
+}
+
+// Reads the record at the offset, giving back its type and a reader over the rest of it.
+func (f *storeFile[V]) readRecord(offset int64) (byte, *byteReader, error) {
+	var header [storeRecordHeaderSize]byte
+	if _, err := f.file.ReadAt(header[:], offset); err != nil {
+		return 0, nil, fmt.Errorf("critbit store: reading record at offset %d: %w", offset, err)
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxStoreRecord {
+		return 0, nil, fmt.Errorf("critbit store: record at offset %d has length %d, which exceeds the limit of %d", offset, size, maxStoreRecord)
+	}
+	data := make([]byte, size)
+	if _, err := f.file.ReadAt(data, offset+storeRecordHeaderSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, fmt.Errorf("critbit store: reading record at offset %d: %w", offset, err)
+	}
+	if crc32.Checksum(data, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+		return 0, nil, fmt.Errorf("critbit store: record at offset %d is corrupted, its checksum doesn't match", offset)
+	}
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("critbit store: empty record at offset %d", offset)
+	}
+	return data[0], &byteReader{data: data, offset: 1}, nil
+}
+
+// Reads the header in the given slot, returning false if it isn't valid.
+func (f *storeFile[V]) readHeader(slot int) (storeHeader, bool) {
+	data := make([]byte, storeHeaderSize+crc32.Size)
+	if _, err := f.file.ReadAt(data, int64(slot)*storeSlotSize); err != nil {
+		return storeHeader{}, false
+	}
+	if string(data[:len(storeMagic)]) != storeMagic || data[len(storeMagic)] != storeFormat ||
+		crc32.Checksum(data[:storeHeaderSize], crcTable) != binary.BigEndian.Uint32(data[storeHeaderSize:]) {
+		return storeHeader{}, false
+	}
+	return storeHeader{
+		version: binary.BigEndian.Uint64(data[8:]),
+		commit:  int64(binary.BigEndian.Uint64(data[16:])),
+		end:     int64(binary.BigEndian.Uint64(data[24:])),
+	}, true
+}
+
+//-- writing --//
+
+// Encodes the nodes in the subtree which haven't been written yet, children before their
+// parents, as if @buf will be written at offset @base.
+func (f *storeFile[V]) appendNodes(buf []byte, n *diskNode[V], base int64, written *[]*diskNode[V]) ([]byte, error) {
+	if n == nil || n.offset != 0 {
+		return buf, nil
+	}
+
+	var record []byte
+	if n.isLeaf {
+		data, err := f.codec.EncodeValue(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("critbit store: encoding value of key %x: %w", n.key, err)
+		}
+		record = append(record, storeLeaf)
+		record = appendBytes(record, n.key)
+		record = appendBytes(record, data)
+	} else {
+		var err error
+		for _, child := range n.children {
+			if buf, err = f.appendNodes(buf, child, base, written); err != nil {
+				return nil, err
+			}
+		}
+		record = append(record, storeInner)
+		record = binary.AppendVarint(record, int64(n.critbyte))
+		record = append(record, n.critbit)
+		record = binary.AppendUvarint(record, uint64(n.count))
+		record = binary.AppendUvarint(record, uint64(n.children[0].offset))
+		record = binary.AppendUvarint(record, uint64(n.children[1].offset))
+	}
+
+	n.offset = base + int64(len(buf))
+	*written = append(*written, n)
+	return appendRecord(buf, record), nil
+}
+
+func appendCommit(buf []byte, c storeCommit) []byte {
+	record := []byte{storeCommitRecord}
+	record = binary.AppendUvarint(record, c.version)
+	record = binary.AppendUvarint(record, uint64(c.root))
+	record = binary.AppendUvarint(record, uint64(c.count))
+	record = binary.AppendUvarint(record, uint64(c.prev))
+	return appendRecord(buf, record)
+}
+
+func appendRecord(buf, record []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(record)))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.Checksum(record, crcTable))
+	return append(buf, record...)
+}
+
+// Checks that an offset read from the record at @from points to an earlier record.
+func checkStoreOffset(offset uint64, from int64) error {
+	if offset < storeDataStart || offset >= uint64(from) {
+		return fmt.Errorf("offset %d is outside the records before it", offset)
+	}
+	return nil
+}
+
+func (f *storeFile[V]) writeAndSync(data []byte, offset int64) error {
+	if _, err := f.file.WriteAt(data, offset); err != nil {
+		return fmt.Errorf("critbit store: %w", err)
+	}
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("critbit store: %w", err)
+	}
+	return nil
+}
+
+//-- copy-on-write updates, which expect the path to the key to be loaded already --//
+
+func (n *diskNode[V]) setLeaf(leaf *diskNode[V]) *diskNode[V] {
+	if n.isLeaf {
+		return leaf
+	}
+	dir := findDirection(leaf.key, n.critbyte, n.critbit)
+	ret := &diskNode[V]{loaded: true, critbyte: n.critbyte, critbit: n.critbit, count: n.count}
+	ret.children[dir] = n.children[dir].setLeaf(leaf)
+	ret.children[1-dir] = n.children[1-dir]
+	return ret
+}
+
+func (n *diskNode[V]) insertLeaf(leaf *diskNode[V], critbyte int, critbit uint8) *diskNode[V] {
+	if n.isLeaf || positionLess(critbyte, critbit, n.critbyte, n.critbit) {
+		//the new critbit goes above this node
+		dir := findDirection(leaf.key, critbyte, critbit)
+		ret := &diskNode[V]{loaded: true, critbyte: critbyte, critbit: critbit, count: n.count + 1}
+		ret.children[dir] = leaf
+		ret.children[1-dir] = n
+		return ret
+	}
+
+	dir := findDirection(leaf.key, n.critbyte, n.critbit)
+	ret := &diskNode[V]{loaded: true, critbyte: n.critbyte, critbit: n.critbit, count: n.count + 1}
+	ret.children[dir] = n.children[dir].insertLeaf(leaf, critbyte, critbit)
+	ret.children[1-dir] = n.children[1-dir]
+	return ret
+}
+
+func (n *diskNode[V]) deleteLeaf(key []byte) *diskNode[V] {
+	if n.isLeaf {
+		return nil
+	}
+	dir := findDirection(key, n.critbyte, n.critbit)
+	child := n.children[dir].deleteLeaf(key)
+	if child == nil {
+		//the sibling takes this node's place, without needing to be read
+		return n.children[1-dir]
+	}
+	ret := &diskNode[V]{loaded: true, critbyte: n.critbyte, critbit: n.critbit, count: n.count - 1}
+	ret.children[dir] = child
+	ret.children[1-dir] = n.children[1-dir]
+	return ret
+}
@@ -0,0 +1,413 @@
+package critbit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStore(t *testing.T, path string) *Store[interface{}] {
+	s, err := OpenStore[interface{}](path, intCodec{})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func storeToMap(t *testing.T, visit func([]byte, func([]byte, interface{}) bool) error) map[string]interface{} {
+	ret := make(map[string]interface{})
+	require.NoError(t, visit(nil, func(key []byte, val interface{}) bool {
+		ret[string(key)] = val
+		return true
+	}))
+	return ret
+}
+
+func storeKey(i int) []byte {
+	return []byte(fmt.Sprintf("key-%08d", i))
+}
+
+// counts the nodes which have been read from the file.
+func countLoaded[V any](n *diskNode[V]) int {
+	if n == nil || !n.loaded {
+		return 0
+	}
+	return 1 + countLoaded(n.children[0]) + countLoaded(n.children[1])
+}
+
+func TestStore_New_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+
+	//act
+	s := openTestStore(t, path)
+
+	//assert
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, 0, s.Version())
+	_, ok, err := s.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	versions, err := s.Versions()
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestStore_SetGetDelete_BeforeCommit(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "store"))
+
+	//act
+	_, err := s.Set([]byte("a"), 1)
+	require.NoError(t, err)
+	_, err = s.Set([]byte("b"), 2)
+	require.NoError(t, err)
+	old, err := s.Set([]byte("a"), 3)
+	require.NoError(t, err)
+	deleted, err := s.Delete([]byte("b"))
+	require.NoError(t, err)
+
+	//assert
+	assert.Equal(t, 1, old)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 1, s.Len())
+	val, ok, err := s.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+}
+
+func TestStore_NilKey_CommitsAndReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	s.Set(nil, 1)
+	s.Set([]byte("a"), 2)
+
+	//act
+	_, err := s.Commit()
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	s = openTestStore(t, path)
+
+	//assert
+	val, ok, err := s.Get(nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+	assert.Equal(t, map[string]interface{}{"": 1, "a": 2}, storeToMap(t, s.VisitAscend))
+	deleted, err := s.Delete([]byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestStore_Rollback_DiscardsChanges(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "store"))
+	s.Set([]byte("a"), 1)
+	_, err := s.Commit()
+	require.NoError(t, err)
+	s.Set([]byte("b"), 2)
+	s.Delete([]byte("a"))
+
+	//act
+	s.Rollback()
+
+	//assert
+	assert.Equal(t, map[string]interface{}{"a": 1}, storeToMap(t, s.VisitAscend))
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestStore_Commit_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	instance, sorted := randomTrie(1000)
+	s := openTestStore(t, path)
+	instance.VisitAscend(nil, func(key []byte, val interface{}) bool {
+		s.Set(key, val)
+		return true
+	})
+
+	//act
+	version, err := s.Commit()
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	reopened := openTestStore(t, path)
+
+	//assert
+	assert.Equal(t, 1, version)
+	assert.Equal(t, 1, reopened.Version())
+	assert.Equal(t, len(sorted), reopened.Len())
+	assert.Equal(t, trieToMap(instance), storeToMap(t, reopened.VisitAscend))
+}
+
+func TestStore_NothingChanged_SameVersion(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "store"))
+	s.Set([]byte("a"), 1)
+	first, _ := s.Commit()
+
+	//act
+	second, err := s.Commit()
+
+	//assert
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestStore_Random_MatchesTrie(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	_, sorted := randomTrie(500)
+	expected := NilTrie()
+	s := openTestStore(t, path)
+
+	for i := 0; i < 3000; i++ {
+		key := []byte(sorted[rand.Intn(len(sorted))])
+		if rand.Intn(3) == 0 {
+			want, _ := expected.Get(key)
+			expected, _ = expected.Delete(key)
+			got, err := s.Delete(key)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		} else {
+			expected, _ = expected.Set(key, i)
+			_, err := s.Set(key, i)
+			require.NoError(t, err)
+		}
+
+		if i%500 == 499 {
+			_, err := s.Commit()
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+			s = openTestStore(t, path)
+		}
+		require.Equal(t, expected.Len(), s.Len())
+	}
+
+	//assert
+	assert.Equal(t, trieToMap(expected), storeToMap(t, s.VisitAscend))
+	for i := 0; i < 100; i++ {
+		from := randBytes()
+		got := make([]string, 0)
+		require.NoError(t, s.VisitAscend(from, func(key []byte, val interface{}) bool {
+			got = append(got, string(key))
+			return len(got) < 10
+		}))
+		want := make([]string, 0)
+		expected.VisitAscend(from, func(key []byte, val interface{}) bool {
+			want = append(want, string(key))
+			return len(want) < 10
+		})
+		assert.Equal(t, want, got, "from %x", from)
+	}
+}
+
+func TestStore_Snapshots_KeepEarlierVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	expected := make([]map[string]interface{}, 0)
+	for v := 0; v < 5; v++ {
+		for i := 0; i < 20; i++ {
+			s.Set([]byte{byte(rand.Intn(30))}, v*100+i)
+		}
+		s.Delete([]byte{byte(rand.Intn(30))})
+		_, err := s.Commit()
+		require.NoError(t, err)
+		expected = append(expected, storeToMap(t, s.VisitAscend))
+	}
+	require.NoError(t, s.Close())
+	s = openTestStore(t, path)
+
+	//act
+	versions, err := s.Versions()
+
+	//assert
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, versions)
+	for _, v := range versions {
+		snap, err := s.Snapshot(v)
+		require.NoError(t, err)
+		assert.Equal(t, v, snap.Version())
+		assert.Equal(t, len(expected[v-1]), snap.Len())
+		assert.Equal(t, expected[v-1], storeToMap(t, snap.VisitAscend), "version %d", v)
+	}
+	_, err = s.Snapshot(6)
+	assert.Error(t, err)
+}
+
+func TestStore_Commit_AppendsOnlyChangedPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	for i := 0; i < 10000; i++ {
+		s.Set(storeKey(i), i)
+	}
+	_, err := s.Commit()
+	require.NoError(t, err)
+	before, _ := os.Stat(path)
+
+	//act
+	s.Set(storeKey(5000), -1)
+	_, err = s.Commit()
+	require.NoError(t, err)
+
+	//assert
+	after, _ := os.Stat(path)
+	assert.True(t, after.Size()-before.Size() < 1000, "grew by %d bytes out of %d", after.Size()-before.Size(), before.Size())
+}
+
+func TestStore_Reopen_LoadsLazily(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	for i := 0; i < 10000; i++ {
+		s.Set(storeKey(i), i)
+	}
+	_, err := s.Commit()
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	s = openTestStore(t, path)
+
+	//act
+	val, ok, err := s.Get(storeKey(1234))
+
+	//assert
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1234, val)
+	assert.True(t, countLoaded(s.root) < 64, "loaded %d nodes", countLoaded(s.root))
+}
+
+func TestStore_InterruptedCommit_Recovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	s.Set([]byte("a"), 1)
+	s.Commit()
+	s.Set([]byte("b"), 2)
+	s.Commit()
+	s.Close()
+	info, _ := os.Stat(path)
+
+	// a commit which wrote some of its data, but not its header
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	file.Write([]byte("garbage from an interrupted commit"))
+	file.Close()
+
+	//act
+	s = openTestStore(t, path)
+
+	//assert
+	assert.Equal(t, 2, s.Version())
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, storeToMap(t, s.VisitAscend))
+	after, _ := os.Stat(path)
+	assert.Equal(t, info.Size(), after.Size(), "the garbage is discarded")
+
+	s.Set([]byte("c"), 3)
+	_, err := s.Commit()
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	s = openTestStore(t, path)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2, "c": 3}, storeToMap(t, s.VisitAscend))
+}
+
+func TestStore_TornHeader_RecoversPreviousCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	s.Set([]byte("a"), 1)
+	s.Commit()
+	s.Set([]byte("b"), 2)
+	s.Commit()
+	s.Close()
+
+	// version 2's header is in slot 0
+	file, _ := os.OpenFile(path, os.O_WRONLY, 0)
+	file.WriteAt([]byte{0xff, 0xff}, 20)
+	file.Close()
+
+	//act
+	s = openTestStore(t, path)
+
+	//assert
+	assert.Equal(t, 1, s.Version())
+	assert.Equal(t, map[string]interface{}{"a": 1}, storeToMap(t, s.VisitAscend))
+}
+
+func TestStore_NotAStore_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	require.NoError(t, os.WriteFile(path, make([]byte, 1000), 0o644))
+
+	//act
+	_, err := OpenStore[interface{}](path, intCodec{})
+
+	//assert
+	assert.Error(t, err)
+}
+
+func TestStore_FailedCommit_CanRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	s.Set([]byte("a"), 1)
+	s.Commit()
+	s.f.codec = failingCodec{}
+	s.Set([]byte("b"), 2)
+
+	//act
+	_, err := s.Commit()
+	s.f.codec = intCodec{}
+	version, retryErr := s.Commit()
+
+	//assert
+	assert.Error(t, err)
+	assert.NoError(t, retryErr)
+	assert.Equal(t, 2, version)
+	require.NoError(t, s.Close())
+	s = openTestStore(t, path)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, storeToMap(t, s.VisitAscend))
+}
+
+func TestStore_CorruptRecord_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s := openTestStore(t, path)
+	s.Set([]byte("abc"), 1)
+	s.Commit()
+	s.Close()
+
+	// the leaf is the first record, and its key starts after the record header, type and length
+	file, _ := os.OpenFile(path, os.O_WRONLY, 0)
+	file.WriteAt([]byte("x"), storeDataStart+storeRecordHeaderSize+2)
+	file.Close()
+
+	//act
+	s = openTestStore(t, path)
+	_, _, err := s.Get([]byte("abc"))
+
+	//assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum")
+}
+
+func TestStore_ChildOffsetOutOfRange_Errors(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "store"))
+	s.Set([]byte("a"), 1)
+	s.Commit()
+	leaf := s.committed.root
+	offset := s.header.end
+	cases := map[string][2]int64{
+		"self":    {leaf, offset},
+		"forward": {leaf, offset + 100},
+		"header":  {leaf, 10},
+	}
+
+	for name, children := range cases {
+		record := []byte{storeInner}
+		record = binary.AppendVarint(record, 0)
+		record = append(record, 0x7f)
+		record = binary.AppendUvarint(record, 2)
+		record = binary.AppendUvarint(record, uint64(children[0]))
+		record = binary.AppendUvarint(record, uint64(children[1]))
+		require.NoError(t, s.f.writeAndSync(appendRecord(nil, record), offset))
+
+		//act
+		err := s.f.load(stubNode[interface{}](offset))
+
+		//assert
+		assert.Error(t, err, name)
+	}
+}
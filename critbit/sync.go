@@ -0,0 +1,509 @@
+package critbit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+A Syncer runs anti-entropy between two replicas of a trie over a stream such as a network
+connection.  One side calls Initiate and the other calls Respond, and afterwards both sides hold
+the union of the two tries.  Deletions are not synchronized: a key which exists on only one side
+is copied to the other.
+
+The replicas compare fingerprints of key ranges, and split any range which differs at its median
+key until the ranges are small enough to exchange outright.  So the data sent is proportional to
+the number of differences times the depth of the trie, rather than to the size of the trie.
+Fingerprints come from Merkle digests of the encoded values, in the same way as hashed mode.  A
+Syncer keeps the digests of the last trie it synced, so repeated syncs of a trie with the same
+Syncer only hash what changed since the last sync.  A Syncer may run several syncs at once. ex:
+
+	s := &critbit.Syncer[interface{}]{Codec: myCodec}
+	go func() { theirs, err = s.Respond(server, theirs) }()
+	ours, err = s.Initiate(client, ours)
+*/
+type Syncer[V any] struct {
+	// Codec encodes the values sent to the other side.  Two values are equal when their
+	// encodings are equal.  Required.
+	Codec ValueCodec[V]
+	// Resolve picks the value to keep for a key whose value differs between the two sides.  Both
+	// sides must use the same function so that they make the same choice.  It is given the
+	// initiator's value first, regardless of which side is calling it.  If it is nil, the
+	// initiator's value is kept.
+	Resolve func(key []byte, initiator, responder V) V
+	// Threshold is the number of keys in a range below which the range is sent outright instead
+	// of being split.  Defaults to 16.
+	Threshold int
+
+	// hashes the encoded values, built from the Codec on first use.
+	hasherOnce sync.Once
+	hasher     *hasher[V]
+	// the digests of the last trie synced, which the next sync reuses where the tries share nodes.
+	last atomic.Pointer[digestNode[V]]
+}
+
+// Initiates a sync of @local with the trie on the other end of @rw, and returns the synchronized
+// trie.  The result is hashed in the same way as @local.
+func (s *Syncer[V]) Initiate(rw io.ReadWriter, local *TypedTrie[V]) (*TypedTrie[V], error) {
+	return s.run(rw, local, true)
+}
+
+// Responds to a sync started by Initiate on the other end of @rw, and returns the synchronized
+// trie.  The result is hashed in the same way as @local.
+func (s *Syncer[V]) Respond(rw io.ReadWriter, local *TypedTrie[V]) (*TypedTrie[V], error) {
+	return s.run(rw, local, false)
+}
+
+const (
+	syncMagic   = "CBSY"
+	syncVersion = 1
+	// the largest key, value or batch that will be read, so a corrupt length can't exhaust memory.
+	maxSyncFrame         = 1 << 28
+	defaultSyncThreshold = 16
+)
+
+const (
+	// the fingerprint of a range, which the receiver compares with its own.
+	syncFingerprint byte = iota + 1
+	// the entries in a range, to which the receiver replies with its own entries in the range.
+	syncItems
+	// the entries in a range, in reply to syncItems.
+	syncItemsReply
+)
+
+// One message exchanged during a sync, about the range of keys from @from inclusive to @to
+// exclusive.  A nil bound leaves that end of the range open.
+type syncMessage struct {
+	kind     byte
+	from, to []byte
+
+	count       uint32
+	fingerprint Digest
+
+	keys, values [][]byte
+}
+
+type syncSession[V any] struct {
+	*Syncer[V]
+	initiator bool
+	// the local trie as it was before the sync, which is what the fingerprints describe.
+	snapshot *TypedTrie[V]
+	result   *Transient[V]
+	changed  bool
+
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (s *Syncer[V]) run(rw io.ReadWriter, local *TypedTrie[V], initiator bool) (*TypedTrie[V], error) {
+	if s.Codec == nil {
+		return nil, errors.New("critbit sync: no value codec")
+	}
+	s.hasherOnce.Do(func() {
+		s.hasher = &hasher[V]{hashValue: s.Codec.EncodeValue}
+	})
+	hash := &hashState[V]{hasher: s.hasher}
+	hash.base.Store(s.last.Load())
+	sess := &syncSession[V]{
+		Syncer:    s,
+		initiator: initiator,
+		snapshot:  &TypedTrie[V]{root: local.root, hash: hash},
+		result:    local.Transient(),
+		r:         bufio.NewReader(rw),
+		w:         bufio.NewWriter(rw),
+	}
+	defer func() {
+		if d := hash.digests.Load(); d != nil {
+			s.last.Store(d)
+		}
+	}()
+
+	if initiator {
+		sess.w.WriteString(syncMagic)
+		sess.w.WriteByte(syncVersion)
+		whole, err := sess.fingerprint(nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := sess.send([]syncMessage{whole}); err != nil {
+			return nil, err
+		}
+	} else if err := sess.readHeader(); err != nil {
+		return nil, err
+	}
+
+	for {
+		in, err := sess.receive()
+		if err != nil {
+			return nil, err
+		}
+		if len(in) == 0 {
+			//the other side is satisfied
+			break
+		}
+		out, err := sess.process(in)
+		if err != nil {
+			return nil, err
+		}
+		if err := sess.send(out); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 {
+			break
+		}
+	}
+	if !sess.changed {
+		return local, nil
+	}
+	return sess.result.Persistent(), nil
+}
+
+// Handles a batch of messages from the other side, giving the batch to send back.
+func (sess *syncSession[V]) process(in []syncMessage) ([]syncMessage, error) {
+	out := make([]syncMessage, 0, len(in))
+	for _, m := range in {
+		switch m.kind {
+		case syncFingerprint:
+			mine, err := sess.fingerprint(m.from, m.to)
+			if err != nil {
+				return nil, err
+			}
+			if mine.count == m.count && mine.fingerprint == m.fingerprint {
+				continue
+			}
+			if mine.count <= uint32(sess.threshold()) {
+				items, err := sess.items(m.from, m.to, syncItems, nil)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, items)
+				continue
+			}
+			// split our side of the range in half, so both halves are smaller than the whole.
+			var base uint32
+			if m.from != nil {
+				base = sess.snapshot.Rank(m.from)
+			}
+			mid, _, _ := sess.snapshot.At(base + mine.count/2)
+			lower, err := sess.fingerprint(m.from, mid)
+			if err != nil {
+				return nil, err
+			}
+			upper, err := sess.fingerprint(mid, m.to)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, lower, upper)
+
+		case syncItems, syncItemsReply:
+			theirs, err := sess.merge(m)
+			if err != nil {
+				return nil, err
+			}
+			if m.kind == syncItems {
+				items, err := sess.items(m.from, m.to, syncItemsReply, theirs)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, items)
+			}
+
+		default:
+			return nil, fmt.Errorf("critbit sync: unknown message type %d", m.kind)
+		}
+	}
+	return out, nil
+}
+
+func (sess *syncSession[V]) threshold() int {
+	if sess.Threshold < 1 {
+		return defaultSyncThreshold
+	}
+	return sess.Threshold
+}
+
+// Gets a fingerprint message describing our keys in the range.
+func (sess *syncSession[V]) fingerprint(from, to []byte) (syncMessage, error) {
+	m := syncMessage{kind: syncFingerprint, from: from, to: to}
+	root := sess.snapshot.root
+	if root == nil {
+		return m, nil
+	}
+	r := &keyRange{fromInclusive: true}
+	if from != nil {
+		r.from = findBound(root, from)
+	}
+	if to != nil {
+		r.to = findBound(root, to)
+	}
+	digests, err := sess.snapshot.hash.digestsOf(root)
+	if err != nil {
+		return m, fmt.Errorf("critbit sync: encoding value: %w", err)
+	}
+	m.fingerprint, m.count = summarizeRange(digests, r, r.from != nil, r.to != nil)
+	return m, nil
+}
+
+// Gets a message holding our entries in the range, leaving out any which the other side sent
+// us with the same value.
+func (sess *syncSession[V]) items(from, to []byte, kind byte, theirs map[string][]byte) (syncMessage, error) {
+	m := syncMessage{kind: kind, from: from, to: to}
+	var err error
+	sess.snapshot.VisitRange(from, true, to, false, func(key []byte, value V) bool {
+		var data []byte
+		if data, err = sess.Codec.EncodeValue(value); err != nil {
+			err = fmt.Errorf("critbit sync: encoding value of key %x: %w", key, err)
+			return false
+		}
+		if other, ok := theirs[string(key)]; ok && bytes.Equal(data, other) {
+			return true
+		}
+		m.keys = append(m.keys, key)
+		m.values = append(m.values, data)
+		return true
+	})
+	return m, err
+}
+
+// Merges the entries from the other side into the result, giving back their encoded values
+// by key.
+func (sess *syncSession[V]) merge(m syncMessage) (map[string][]byte, error) {
+	theirs := make(map[string][]byte, len(m.keys))
+	for i, key := range m.keys {
+		theirs[string(key)] = m.values[i]
+
+		value, err := sess.Codec.DecodeValue(m.values[i])
+		if err != nil {
+			return nil, fmt.Errorf("critbit sync: decoding value of key %x: %w", key, err)
+		}
+		if leaf := getLeaf(sess.snapshot.root, key); leaf != nil {
+			mine, err := sess.Codec.EncodeValue(leaf.value)
+			if err != nil {
+				return nil, fmt.Errorf("critbit sync: encoding value of key %x: %w", key, err)
+			}
+			if bytes.Equal(mine, m.values[i]) {
+				continue
+			}
+			value = sess.resolve(key, leaf.value, value)
+		}
+		sess.result.Set(key, value)
+		sess.changed = true
+	}
+	return theirs, nil
+}
+
+func (sess *syncSession[V]) resolve(key []byte, mine, theirs V) V {
+	initiator, responder := mine, theirs
+	if !sess.initiator {
+		initiator, responder = theirs, mine
+	}
+	if sess.Resolve == nil {
+		return initiator
+	}
+	return sess.Resolve(key, initiator, responder)
+}
+
+// Gets the XOR of the leaf digests in a range of keys and the number of keys in it.  Subtrees
+// which fall entirely inside the range use their computed digest, so this takes O(depth).
+func summarizeRange[V any](d *digestNode[V], r *keyRange, needsFrom, needsTo bool) (Digest, uint32) {
+	n := d.node
+	if !needsFrom && !needsTo {
+		return d.xor, n.size()
+	}
+	in, ok := n.(*inner[V])
+	if !ok {
+		key := n.(*leaf[V]).key
+		if needsTo && bytes.Compare(key, r.to.key) >= 0 {
+			return Digest{}, 0
+		}
+		if needsFrom && bytes.Compare(key, r.from.key) < 0 {
+			return Digest{}, 0
+		}
+		return d.xor, 1
+	}
+
+	//this is a node
+	fromDirection, toDirection := 0, 1
+	if needsFrom {
+		fromDirection = r.from.direction(in.critbyte, in.critbit)
+	}
+	if needsTo {
+		toDirection = r.to.direction(in.critbyte, in.critbit)
+	}
+
+	var sum Digest
+	var count uint32
+	if fromDirection == 0 {
+		sum, count = summarizeRange(d.children[0], r, needsFrom, needsTo && toDirection == 0)
+	}
+	if toDirection == 1 {
+		sum1, count1 := summarizeRange(d.children[1], r, needsFrom && fromDirection == 1, needsTo)
+		sum, count = xorDigest(sum, sum1), count+count1
+	}
+	return sum, count
+}
+
+//-- wire format --//
+
+// Writes and flushes a batch of messages.  An empty batch tells the other side we're done.
+func (sess *syncSession[V]) send(batch []syncMessage) error {
+	var buf []byte
+	buf = binary.AppendUvarint(buf, uint64(len(batch)))
+	for _, m := range batch {
+		buf = append(buf, m.kind)
+		buf = appendOptionalBytes(buf, m.from)
+		buf = appendOptionalBytes(buf, m.to)
+		if m.kind == syncFingerprint {
+			buf = binary.AppendUvarint(buf, uint64(m.count))
+			buf = append(buf, m.fingerprint[:]...)
+			continue
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(m.keys)))
+		for i := range m.keys {
+			buf = appendBytes(buf, m.keys[i])
+			buf = appendBytes(buf, m.values[i])
+		}
+	}
+
+	if _, err := sess.w.Write(buf); err != nil {
+		return fmt.Errorf("critbit sync: writing batch: %w", err)
+	}
+	if err := sess.w.Flush(); err != nil {
+		return fmt.Errorf("critbit sync: writing batch: %w", err)
+	}
+	return nil
+}
+
+func (sess *syncSession[V]) readHeader() error {
+	header := make([]byte, len(syncMagic)+1)
+	if _, err := io.ReadFull(sess.r, header); err != nil {
+		return fmt.Errorf("critbit sync: reading header: %w", err)
+	}
+	if string(header[:len(syncMagic)]) != syncMagic {
+		return fmt.Errorf("critbit sync: bad header %q", header[:len(syncMagic)])
+	}
+	if header[len(syncMagic)] != syncVersion {
+		return fmt.Errorf("critbit sync: unsupported version %d", header[len(syncMagic)])
+	}
+	return nil
+}
+
+// Reads a batch of messages written by send.
+func (sess *syncSession[V]) receive() ([]syncMessage, error) {
+	batch, err := sess.readBatch()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("critbit sync: reading batch: %w", err)
+	}
+	return batch, nil
+}
+
+func (sess *syncSession[V]) readBatch() ([]syncMessage, error) {
+	n, err := readLength(sess.r)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]syncMessage, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		m := syncMessage{}
+		if m.kind, err = sess.r.ReadByte(); err != nil {
+			return nil, err
+		}
+		if m.from, err = readOptionalBytes(sess.r); err != nil {
+			return nil, err
+		}
+		if m.to, err = readOptionalBytes(sess.r); err != nil {
+			return nil, err
+		}
+
+		if m.kind == syncFingerprint {
+			count, err := readLength(sess.r)
+			if err != nil {
+				return nil, err
+			}
+			m.count = uint32(count)
+			if _, err := io.ReadFull(sess.r, m.fingerprint[:]); err != nil {
+				return nil, err
+			}
+		} else {
+			count, err := readLength(sess.r)
+			if err != nil {
+				return nil, err
+			}
+			// the count isn't trusted until the items have actually been read.
+			m.keys = make([][]byte, 0, min(count, 1024))
+			m.values = make([][]byte, 0, min(count, 1024))
+			for j := 0; j < count; j++ {
+				key, err := readBytes(sess.r)
+				if err != nil {
+					return nil, err
+				}
+				value, err := readBytes(sess.r)
+				if err != nil {
+					return nil, err
+				}
+				m.keys = append(m.keys, key)
+				m.values = append(m.values, value)
+			}
+		}
+		batch = append(batch, m)
+	}
+	return batch, nil
+}
+
+func appendBytes(buf, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// writes nil as a zero length, and anything else as its length plus one.
+func appendOptionalBytes(buf, data []byte) []byte {
+	if data == nil {
+		return append(buf, 0)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(data))+1)
+	return append(buf, data...)
+}
+
+func readLength(r *bufio.Reader) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxSyncFrame {
+		return 0, fmt.Errorf("length %d exceeds the limit of %d", n, maxSyncFrame)
+	}
+	return int(n), nil
+}
+
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readOptionalBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := readLength(r)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	data := make([]byte, n-1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
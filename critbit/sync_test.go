@@ -0,0 +1,290 @@
+package critbit
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodes int values as decimal strings.
+type intCodec struct{}
+
+func (intCodec) EncodeValue(v interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(v.(int))), nil
+}
+
+func (intCodec) DecodeValue(data []byte) (interface{}, error) {
+	return strconv.Atoi(string(data))
+}
+
+// counts the bytes written through a connection.
+type countingConn struct {
+	net.Conn
+	written int
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written += n
+	return n, err
+}
+
+// syncs two tries over a pipe, returning both results and the number of bytes sent.
+func syncPipe(t *testing.T, s *Syncer[interface{}], initiator, responder *Trie) (*Trie, *Trie, int) {
+	client, server := net.Pipe()
+	defer client.Close()
+	counted := &countingConn{Conn: client}
+	counted2 := &countingConn{Conn: server}
+
+	done := make(chan error)
+	go func() {
+		var err error
+		responder, err = s.Respond(counted2, responder)
+		server.Close()
+		done <- err
+	}()
+	initiator, err := s.Initiate(counted, initiator)
+	require.NoError(t, err, "initiate")
+	require.NoError(t, <-done, "respond")
+	return initiator, responder, counted.written + counted2.written
+}
+
+func TestSync_BothEmpty(t *testing.T) {
+	s := &Syncer[interface{}]{Codec: intCodec{}}
+
+	//act
+	a, b, _ := syncPipe(t, s, NilTrie(), NilTrie())
+
+	//assert
+	assert.Equal(t, 0, a.Len())
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestSync_OneSideEmpty_CopiesEverything(t *testing.T) {
+	full, sorted := randomTrie(200)
+	s := &Syncer[interface{}]{Codec: intCodec{}}
+
+	//act
+	a, b, _ := syncPipe(t, s, NilTrie(), full)
+
+	//assert
+	assert.Equal(t, len(sorted), a.Len())
+	assert.Equal(t, trieToMap(full), trieToMap(a))
+	assert.True(t, full == b, "responder had nothing to learn")
+}
+
+func TestSync_Identical_SendsOnlyRootFingerprint(t *testing.T) {
+	full, _ := randomTrie(1000)
+	s := &Syncer[interface{}]{Codec: intCodec{}}
+
+	//act
+	a, b, sent := syncPipe(t, s, full, full)
+
+	//assert
+	assert.Equal(t, trieToMap(full), trieToMap(a))
+	assert.Equal(t, trieToMap(full), trieToMap(b))
+	assert.True(t, sent < 64, "sent %d bytes", sent)
+}
+
+func TestSync_SmallDivergence_SendsLittle(t *testing.T) {
+	base, sorted := randomTrie(10000)
+	ours, theirs := base, base
+	for i := 0; i < 5; i++ {
+		ours, _ = ours.Set(append([]byte("ours"), randBytes()...), -i)
+		theirs, _ = theirs.Set(append([]byte("theirs"), randBytes()...), -i)
+		ours, _ = ours.Set([]byte(sorted[i*1000]), 100000+i)
+	}
+	// both sides changed the same key
+	ours, _ = ours.Set([]byte(sorted[42]), 1)
+	theirs, _ = theirs.Set([]byte(sorted[42]), 2)
+
+	var conflicts []string
+	s := &Syncer[interface{}]{
+		Codec: intCodec{},
+		Resolve: func(key []byte, initiator, responder interface{}) interface{} {
+			conflicts = append(conflicts, string(key))
+			return initiator.(int) + responder.(int)
+		},
+	}
+
+	//act
+	a, b, sent := syncPipe(t, s, ours, theirs)
+
+	//assert
+	expected := trieToMap(Union(ours, theirs, func(key []byte, x, y interface{}) interface{} {
+		return x.(int) + y.(int)
+	}))
+	assert.Equal(t, expected, trieToMap(a))
+	assert.Equal(t, expected, trieToMap(b))
+	assert.Equal(t, 3, expected[sorted[42]])
+	// both sides resolve the 5 keys which only we changed, as well as sorted[42]
+	assert.Equal(t, 12, len(conflicts))
+
+	_, _, full := syncPipe(t, s, NilTrie(), base)
+	assert.True(t, sent < full/5, "sent %d bytes, where copying everything takes %d", sent, full)
+}
+
+func TestSync_NilResolve_InitiatorWins(t *testing.T) {
+	ours, _ := NilTrie().Set([]byte("a"), 1)
+	theirs, _ := NilTrie().Set([]byte("a"), 2)
+	theirs, _ = theirs.Set([]byte("b"), 3)
+	s := &Syncer[interface{}]{Codec: intCodec{}}
+
+	//act
+	a, b, _ := syncPipe(t, s, ours, theirs)
+
+	//assert
+	expected := map[string]interface{}{"a": 1, "b": 3}
+	assert.Equal(t, expected, trieToMap(a))
+	assert.Equal(t, expected, trieToMap(b))
+}
+
+func TestSync_Hashed_StaysHashed(t *testing.T) {
+	ours, _ := randomTrie(500)
+	ours = ours.Hashed(hashInt)
+	theirs, _ := ours.Set([]byte("new key"), 7)
+	s := &Syncer[interface{}]{Codec: intCodec{}, Threshold: 4}
+
+	//act
+	a, b, _ := syncPipe(t, s, ours, theirs)
+
+	//assert
+	assert.Equal(t, theirs.RootHash(), a.RootHash())
+	assert.True(t, theirs == b, "responder had nothing to learn")
+}
+
+// counts the values encoded.
+type countingCodec struct {
+	intCodec
+	encoded *atomic.Int32
+}
+
+func (c countingCodec) EncodeValue(v interface{}) ([]byte, error) {
+	c.encoded.Add(1)
+	return c.intCodec.EncodeValue(v)
+}
+
+func TestSync_Repeated_OnlyHashesChanges(t *testing.T) {
+	ours, sorted := randomTrie(500)
+	var encoded atomic.Int32
+	s := &Syncer[interface{}]{Codec: countingCodec{encoded: &encoded}}
+	a, _, _ := syncPipe(t, s, ours, ours)
+	require.Equal(t, int32(2*len(sorted)), encoded.Load(), "the first sync hashes every value on both sides")
+
+	//act
+	encoded.Store(0)
+	changed, _ := a.Set([]byte(sorted[0]), -1)
+	syncPipe(t, s, changed, changed)
+
+	//assert
+	assert.Equal(t, int32(2), encoded.Load(), "only the changed value should be hashed on each side")
+}
+
+func TestSync_Random_Converges(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		base, sorted := randomTrie(300)
+		ours, theirs := base, base
+		for j := 0; j < i*3; j++ {
+			ours = randomEdit(ours, sorted, j)
+			theirs = randomEdit(theirs, sorted, -j)
+		}
+		s := &Syncer[interface{}]{Codec: intCodec{}, Threshold: 1 + i%5}
+
+		//act
+		a, b, _ := syncPipe(t, s, ours, theirs)
+
+		//assert
+		expected := trieToMap(Union(theirs, ours, nil))
+		require.Equal(t, expected, trieToMap(a), "initiator, round %d", i)
+		require.Equal(t, expected, trieToMap(b), "responder, round %d", i)
+	}
+}
+
+func TestSync_BadHeader_Errors(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		client.Write([]byte("HTTP/1.1"))
+		client.Close()
+	}()
+
+	//act
+	_, err := (&Syncer[interface{}]{Codec: intCodec{}}).Respond(server, NilTrie())
+
+	//assert
+	assert.Error(t, err)
+}
+
+func TestSync_ClosedEarly_Errors(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		io.ReadFull(server, make([]byte, 5))
+		server.Close()
+	}()
+	full, _ := randomTrie(10)
+
+	//act
+	_, err := (&Syncer[interface{}]{Codec: intCodec{}}).Initiate(client, full)
+
+	//assert
+	assert.Error(t, err)
+}
+
+func TestSync_HugeItemCount_ErrorsWithoutAllocating(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		// one batch of one syncItems message with open bounds, claiming 1<<27 items but holding none
+		frame := []byte(syncMagic + "\x01")
+		frame = append(frame, 0x01, syncItems, 0x00, 0x00)
+		frame = binary.AppendUvarint(frame, 1<<27)
+		client.Write(frame)
+		client.Close()
+	}()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	//act
+	_, err := (&Syncer[interface{}]{Codec: intCodec{}}).Respond(server, NilTrie())
+
+	//assert
+	runtime.ReadMemStats(&after)
+	assert.Error(t, err)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 16<<20, "allocated %d bytes", after.TotalAlloc-before.TotalAlloc)
+}
+
+func TestSync_NoCodec_Errors(t *testing.T) {
+	client, _ := net.Pipe()
+
+	//act
+	_, err := (&Syncer[interface{}]{}).Initiate(client, NilTrie())
+
+	//assert
+	assert.Error(t, err)
+}
+
+var errCantEncode = errors.New("can't encode")
+
+type failingCodec struct{ intCodec }
+
+func (failingCodec) EncodeValue(v interface{}) ([]byte, error) {
+	return nil, errCantEncode
+}
+
+func TestSync_EncodeError_Errors(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	full, _ := randomTrie(10)
+
+	//act
+	_, err := (&Syncer[interface{}]{Codec: failingCodec{}}).Initiate(client, full)
+
+	//assert
+	assert.Error(t, err)
+}
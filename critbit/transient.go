@@ -0,0 +1,181 @@
+package critbit
+
+import (
+	"bytes"
+)
+
+/*
+A Transient is a mutable builder for applying a batch of changes to a trie.  Rather than
+copying the path to the root on every write, it modifies in place any nodes that it has
+already copied.  The snapshot it was created from is never affected, ex:
+
+	tr := tree.Transient()
+	for i, key := range keys {
+		tr.Set(key, i)
+	}
+	tree = tr.Persistent()
+
+A transient must not be shared between goroutines, and it panics if it is used after
+Persistent is called.
+*/
+type Transient[V any] struct {
+	root node[V]
+	hash *hashState[V]
+	// set once Persistent has been called.
+	frozen bool
+}
+
+// Gets a transient builder initialized with the contents of this trie.
+func (t *TypedTrie[V]) Transient() *Transient[V] {
+	return &Transient[V]{
+		root: t.root,
+		hash: t.hash,
+	}
+}
+
+// Freezes the transient into an immutable trie.  The transient may no longer be used.
+func (t *Transient[V]) Persistent() *TypedTrie[V] {
+	t.ensureEditable()
+	t.frozen = true
+	release(t.root)
+	return &TypedTrie[V]{root: t.root, hash: t.hash.derive()}
+}
+
+// Gets an item by its key.  Returns the item and a boolean which is true if the item existed.
+func (t *Transient[V]) Get(key []byte) (V, bool) {
+	t.ensureEditable()
+	return (&TypedTrie[V]{root: t.root}).Get(key)
+}
+
+// Gets the number of items in the transient.
+func (t *Transient[V]) Len() uint32 {
+	t.ensureEditable()
+	if t.root == nil {
+		return 0
+	}
+	return t.root.size()
+}
+
+// Sets the given key to the given value, returning the previous value.
+func (t *Transient[V]) Set(key []byte, value V) V {
+	t.ensureEditable()
+	var zero V
+	if any(value) == nil {
+		panic("value cannot be nil")
+	}
+
+	if t.root == nil {
+		t.root = &leaf[V]{
+			key:   key,
+			value: value,
+		}
+		return zero
+	}
+
+	n := t.root.findBestLeaf(key)
+	if bytes.Equal(key, n.key) {
+		prev := n.value
+		t.root = t.root.setLeafInPlace(key, value)
+		return prev
+	}
+
+	critbyte, critbit := findCritbit(key, n.key)
+	t.root = t.root.insertLeafInPlace(key, value, critbyte, critbit)
+	return zero
+}
+
+// Deletes the key-value pair for the given key, returning the deleted value.
+func (t *Transient[V]) Delete(key []byte) V {
+	t.ensureEditable()
+	var zero V
+	if t.root == nil {
+		return zero
+	}
+
+	n := t.root.findBestLeaf(key)
+	if bytes.Equal(key, n.key) {
+		prev := n.value
+		t.root = t.root.deleteLeafInPlace(key)
+		return prev
+	}
+	return zero
+}
+
+func (t *Transient[V]) ensureEditable() {
+	if t.frozen {
+		panic("transient used after call to Persistent")
+	}
+}
+
+//-- internal functions --//
+
+// Gets a version of this node which can be modified in place by the transient.
+func (n *inner[V]) editable() *inner[V] {
+	if n.owned {
+		return n
+	}
+	return &inner[V]{
+		critbyte: n.critbyte,
+		critbit:  n.critbit,
+		owned:    true,
+		children: n.children,
+		count:    n.count,
+	}
+}
+
+// Clears the ownership of the nodes a transient created, so that they are safe to share.
+// Those nodes always form a subtree at the root, since every write copies the path to it.
+func release[V any](n node[V]) {
+	for in, ok := n.(*inner[V]); ok && in.owned; in, ok = n.(*inner[V]) {
+		in.owned = false
+		release(in.children[0])
+		n = in.children[1]
+	}
+}
+
+// Leaves are never modified in place, since replacing one costs no more than copying it.
+func (l *leaf[V]) setLeafInPlace(key []byte, value V) node[V] {
+	return l.setLeaf(key, value)
+}
+
+func (n *inner[V]) setLeafInPlace(key []byte, value V) node[V] {
+	n = n.editable()
+	direction := findDirection(key, n.critbyte, n.critbit)
+	n.children[direction] = n.children[direction].setLeafInPlace(key, value)
+	return n
+}
+
+func (l *leaf[V]) insertLeafInPlace(key []byte, value V, critbyte int, critbit uint8) node[V] {
+	return insertAbove[V](l, &leaf[V]{key: key, value: value}, critbyte, critbit, true)
+}
+
+func (n *inner[V]) insertLeafInPlace(key []byte, value V, critbyte int, critbit uint8) node[V] {
+	if n.critbyte > critbyte || (n.critbyte == critbyte && n.critbit > critbit) {
+		return insertAbove[V](n, &leaf[V]{key: key, value: value}, critbyte, critbit, true)
+	}
+
+	n = n.editable()
+	n.count++
+	dir := findDirection(key, n.critbyte, n.critbit)
+	n.children[dir] = n.children[dir].insertLeafInPlace(key, value, critbyte, critbit)
+	return n
+}
+
+func (l *leaf[V]) deleteLeafInPlace(key []byte) node[V] {
+	//this is the expected leaf delete it by returning nil
+	return nil
+}
+
+func (n *inner[V]) deleteLeafInPlace(key []byte) node[V] {
+	dir := findDirection(key, n.critbyte, n.critbit)
+	result := n.children[dir].deleteLeafInPlace(key)
+	if result == nil {
+		//the child was deleted - this node is no longer necessary
+		return n.children[1-dir]
+	}
+
+	n = n.editable()
+	n.count--
+	n.children[dir] = result
+	return n
+}
@@ -0,0 +1,162 @@
+package critbit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransient_NilTrie(t *testing.T) {
+	tr := NilTrie().Transient()
+
+	//act
+	old := tr.Set([]byte{0x01}, 1)
+	result := tr.Persistent()
+
+	//assert
+	assert.Nil(t, old)
+	assert.Equal(t, 1, result.Len())
+	assert.Equal(t, 0, NilTrie().Len(), "original tree should remain immutable")
+}
+
+func TestTransient_SetAndDelete_OriginalUnaffected(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 124)
+	instance, _ = instance.Set([]byte{0x01, 0x03, 0x03}, 133)
+	before := instance.DumpTrie()
+	tr := instance.Transient()
+
+	//act
+	old := tr.Set([]byte{0x01, 0x02, 0x04}, 999)
+	tr.Set([]byte{0x01, 0x02, 0x05}, 125)
+	was := tr.Delete([]byte{0x01, 0x03, 0x03})
+	missing := tr.Delete([]byte{0x05})
+	result := tr.Persistent()
+
+	//assert
+	assert.Equal(t, 124, old)
+	assert.Equal(t, 133, was)
+	assert.Nil(t, missing)
+	assert.Equal(t, 3, result.Len())
+	got, ok := result.Get([]byte{0x01, 0x02, 0x04})
+	require.True(t, ok)
+	assert.Equal(t, 999, got)
+	_, ok = result.Get([]byte{0x01, 0x03, 0x03})
+	assert.False(t, ok)
+
+	assert.Equal(t, before, instance.DumpTrie(), "original tree should remain immutable")
+	got, _ = instance.Get([]byte{0x01, 0x02, 0x04})
+	assert.Equal(t, 124, got, "original tree should remain immutable")
+}
+
+func TestTransient_ModifiesOwnedNodesInPlace(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01}, 1)
+	instance, _ = instance.Set([]byte{0x02}, 2)
+	tr := instance.Transient()
+
+	//act
+	tr.Set([]byte{0x01}, 11)
+	root := tr.root
+	tr.Set([]byte{0x02}, 22)
+
+	//assert
+	assert.True(t, root == tr.root, "second write should reuse the copied root")
+	assert.True(t, instance.root != tr.root, "first write should copy the root")
+}
+
+func TestTransient_PersistentSnapshotUnaffectedByLaterTransient(t *testing.T) {
+	tr := NilTrie().Transient()
+	tr.Set([]byte{0x01}, 1)
+	tr.Set([]byte{0x02}, 2)
+	first := tr.Persistent()
+	before := first.DumpTrie()
+
+	//act
+	tr2 := first.Transient()
+	tr2.Set([]byte{0x01}, 11)
+	tr2.Delete([]byte{0x02})
+	tr2.Set([]byte{0x03}, 3)
+
+	//assert
+	assert.Equal(t, before, first.DumpTrie())
+	got, _ := first.Get([]byte{0x01})
+	assert.Equal(t, 1, got)
+}
+
+func TestTransient_Persistent_ReleasesOwnedNodes(t *testing.T) {
+	tr := NilTrie().Transient()
+	for i := 0; i < 100; i++ {
+		tr.Set([]byte{byte(i * 7)}, i)
+	}
+
+	//act
+	tree := tr.Persistent()
+
+	//assert
+	owned := 0
+	var walk func(n node[interface{}])
+	walk = func(n node[interface{}]) {
+		if in, ok := n.(*inner[interface{}]); ok {
+			if in.owned {
+				owned++
+			}
+			walk(in.children[0])
+			walk(in.children[1])
+		}
+	}
+	walk(tree.root)
+	assert.Equal(t, 0, owned, "no node of a persistent trie should be owned")
+}
+
+func TestTransient_UseAfterPersistent_Panics(t *testing.T) {
+	tr := NilTrie().Transient()
+	tr.Set([]byte{0x01}, 1)
+	tr.Persistent()
+
+	//act & assert
+	assertPanics(t, func() { tr.Set([]byte{0x02}, 2) }, "set")
+	assertPanics(t, func() { tr.Get([]byte{0x01}) }, "get")
+	assertPanics(t, func() { tr.Delete([]byte{0x01}) }, "delete")
+	assertPanics(t, func() { tr.Len() }, "len")
+	assertPanics(t, func() { tr.Persistent() }, "persistent")
+}
+
+func TestTransient_Random_MatchesPersistent(t *testing.T) {
+	expected, sorted := randomTrie(500)
+	tr := NilTrie().Transient()
+	for i, k := range sorted {
+		v, _ := expected.Get([]byte(k))
+		tr.Set([]byte(k), v)
+		if i%3 == 0 {
+			tr.Set([]byte(k), i)
+			tr.Set([]byte(k), v)
+		}
+	}
+	snapshot := tr.Persistent()
+	tr = snapshot.Transient()
+
+	//act
+	for i, k := range sorted {
+		if i%2 == 0 {
+			tr.Delete([]byte(k))
+			expected, _ = expected.Delete([]byte(k))
+		}
+	}
+	result := tr.Persistent()
+
+	//assert
+	assert.Equal(t, expected.DumpTrie(), result.DumpTrie())
+	assertCounts(t, result.root)
+	assert.Equal(t, len(sorted), snapshot.Len(), "snapshot should remain immutable")
+	assertCounts(t, snapshot.root)
+}
+
+func assertPanics(t *testing.T, fn func(), msg string) {
+	defer func() {
+		if err := recover(); err == nil {
+			assert.Fail(t, "should have panicked", msg)
+		}
+	}()
+	fn()
+}
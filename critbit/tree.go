@@ -2,29 +2,80 @@ package critbit
 
 import (
 	"bytes"
+	"math"
 )
 
-// A copy-on-write critbit Trie.  It stores key-value pairs where the key is a byte slice.
+// A copy-on-write critbit Trie.  It stores key-value pairs where the key is a byte slice
+// and the value is of type V.
 // The internal implementation is based on https://github.com/agl/critbit/blob/master/critbit.pdf
-type Trie struct {
-	root *node
+type TypedTrie[V any] struct {
+	root node[V]
+
+	// the Merkle digests of the nodes, if this trie is in hashed mode.
+	hash *hashState[V]
+}
+
+// A TypedTrie storing interface{} values.  This is the original untyped API, kept so that
+// existing callers continue to compile.
+type Trie = TypedTrie[interface{}]
+
+// A node in the trie.  It is either a *leaf holding a key-value pair, or an *inner node
+// joining two subtrees at their critical bit.  Keeping the two apart means that neither one
+// pays for the other's fields, which roughly halves the memory used by a trie.
+type node[V any] interface {
+	// gets the number of leaves in this subtree.
+	size() uint32
+	// gets the position of this node's critical bit, see positionLess.
+	position() (int, uint8)
+
+	findBestLeaf(key []byte) *leaf[V]
+	findPrefix(prefix []byte) node[V]
+	edge(direction int) *leaf[V]
+
+	visitAscend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool)
+	visitDescend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool)
+	visitRange(r *keyRange, visitor func([]byte, V) bool, needsFrom, needsTo bool) bool
+
+	setLeaf(key []byte, value V) node[V]
+	insertLeaf(key []byte, value V, critbyte int, critbit uint8) node[V]
+	deleteLeaf(key []byte) node[V]
+	setLeafInPlace(key []byte, value V) node[V]
+	insertLeafInPlace(key []byte, value V, critbyte int, critbit uint8) node[V]
+	deleteLeafInPlace(key []byte) node[V]
+	split(b *bound) (node[V], node[V])
+}
+
+// An external node holding a key and its value.
+type leaf[V any] struct {
+	//the key at this leaf.  All leafs have a non-nil key.
+	key []byte
+	//The value at this leaf.  All leafs have a non-nil value.
+	value V
 }
 
-type node struct {
+// An internal node, which splits its keys by the value of a single bit.
+type inner[V any] struct {
 	// the index into the byte array of the byte containing the critical bit.
 	critbyte int
 	// a bitmask where all bits except the critical bit are true.  This allows more efficient
 	// identification of the critical bit.
 	critbit uint8
-
-	children [2]*node
+	// true while this node belongs to the live transient which created it, and may be
+	// modified in place.  Cleared when the transient is frozen; it fits in the padding
+	// after critbit, so costs no memory.
+	owned bool
 
 	count uint32
 
-	//the key at this leaf.  All external leafs have a non-nil key.
-	key []byte
-	//The value at this leaf.  All external leafs have a non-nil value, and do not point to other nodes.
-	value interface{}
+	children [2]node[V]
+}
+
+func (l *leaf[V]) size() uint32 {
+	return 1
+}
+
+func (n *inner[V]) size() uint32 {
+	return n.count
 }
 
 var nilTrie *Trie = &Trie{}
@@ -34,28 +85,35 @@ func NilTrie() *Trie {
 	return nilTrie
 }
 
+// Gets an empty trie holding values of type V.  The zero value of a TypedTrie is
+// also an empty trie.
+func NilTypedTrie[V any]() *TypedTrie[V] {
+	return &TypedTrie[V]{}
+}
+
 //-- read operations --//
 
 // Gets an item out of the tree by its key.  Returns the item and a boolean which is
 // true if the item existed.
-func (t *Trie) Get(key []byte) (interface{}, bool) {
+func (t *TypedTrie[V]) Get(key []byte) (V, bool) {
+	var zero V
 	if t.root == nil {
-		return nil, false
+		return zero, false
 	}
 
 	n := t.root.findBestLeaf(key)
 	if bytes.Equal(key, n.key) {
 		return n.value, true
 	}
-	return nil, false
+	return zero, false
 }
 
 // Gets the number of items in the tree.
-func (t *Trie) Len() uint32 {
+func (t *TypedTrie[V]) Len() uint32 {
 	if t.root == nil {
 		return 0
 	}
-	return t.root.count
+	return t.root.size()
 }
 
 /*
@@ -63,6 +121,7 @@ VisitAscend applies the visitor function to all key-value pairs in the trie.
 The optional @from parameter specifies an inclusive starting key, and the visitor's
 boolean return value indicates whether to continue.
 This can be used to perform a prefix search on keys, ex:
+
 	//get every word in our dictionary trie that starts with "b"
 	words := make([]string, 0, 8)
 	myDictionary.VisitAscend([]byte("b"), func(key []byte, val interface{} bool){
@@ -73,29 +132,34 @@ This can be used to perform a prefix search on keys, ex:
 		return true
 	})
 	words == []string{"b", "baaa", "bag", "barn", "beetlejuice", "boo", "booger", "boogie"}
+
+VisitPrefix does the same without needing to check each key.
 */
-func (t *Trie) VisitAscend(from []byte, visitor func([]byte, interface{}) bool) {
-	t.root.visitAscend(from, visitor, from != nil)
+func (t *TypedTrie[V]) VisitAscend(from []byte, visitor func([]byte, V) bool) {
+	if t.root == nil {
+		return
+	}
+	var b *bound
+	if from != nil {
+		b = findBound(t.root, from)
+	}
+	t.root.visitAscend(b, visitor, b != nil)
 }
 
-func (n *node) visitAscend(from []byte, visitor func([]byte, interface{}) bool, needsCompare bool) (bool, bool) {
-	if n == nil {
-		return false, false
-	}
-	if n.key != nil {
-		// needsCompare is a short-circuit, if we've determined we're
-		// already past the lower bound
-		if !needsCompare || bytes.Compare(n.key, from) >= 0 {
-			return visitor(n.key, n.value), false
-		}
-		return true, needsCompare //continue up the tree, comparing until we find the first one
+func (l *leaf[V]) visitAscend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool) {
+	// needsCompare is a short-circuit, if we've determined we're
+	// already past the lower bound
+	if !needsCompare || bytes.Compare(l.key, from.key) >= 0 {
+		return visitor(l.key, l.value), false
 	}
+	return true, needsCompare //continue up the tree, comparing until we find the first one
+}
 
-	//this is a node
+func (n *inner[V]) visitAscend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool) {
 	direction := 0
 	if needsCompare {
 		// navigate down the tree to short-circuit as many as possible of the keys lt from
-		direction = findDirection(from, n.critbyte, n.critbit)
+		direction = from.direction(n.critbyte, n.critbit)
 	}
 
 	var result bool
@@ -103,88 +167,329 @@ func (n *node) visitAscend(from []byte, visitor func([]byte, interface{}) bool,
 		if result, needsCompare = n.children[0].visitAscend(from, visitor, needsCompare); !result {
 			return false, needsCompare
 		}
+		// if the direction we chose at this node was zero, then the 1 child
+		// is gt the key so we can start short-circuting the comparisons.
+		needsCompare = false
 	}
-	// if the direction we chose at this node was zero, then the 1 child
-	// is gt the key so we can start short-circuting the comparisons.
 	if result, needsCompare = n.children[1].visitAscend(from, visitor, needsCompare); !result {
 		return false, needsCompare
 	}
 	return true, needsCompare
 }
 
+/*
+VisitDescend applies the visitor function to all key-value pairs in the trie in
+descending order.  The optional @from parameter specifies an inclusive upper bound
+where iteration starts, and the visitor's boolean return value indicates whether to continue.
+This can be used to scan backwards from a point, ex:
+
+	//get the latest 50 events at or before a timestamp-keyed position
+	events := make([]Event, 0, 50)
+	eventLog.VisitDescend(timestampKey(t), func(key []byte, val Event) bool {
+		events = append(events, val)
+		return len(events) < 50
+	})
+*/
+func (t *TypedTrie[V]) VisitDescend(from []byte, visitor func([]byte, V) bool) {
+	if t.root == nil {
+		return
+	}
+	var b *bound
+	if from != nil {
+		b = findBound(t.root, from)
+	}
+	t.root.visitDescend(b, visitor, b != nil)
+}
+
+func (l *leaf[V]) visitDescend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool) {
+	// needsCompare is a short-circuit, if we've determined we're
+	// already below the upper bound
+	if !needsCompare || bytes.Compare(l.key, from.key) <= 0 {
+		return visitor(l.key, l.value), false
+	}
+	return true, needsCompare //continue up the tree, comparing until we find the first one
+}
+
+func (n *inner[V]) visitDescend(from *bound, visitor func([]byte, V) bool, needsCompare bool) (bool, bool) {
+	direction := 1
+	if needsCompare {
+		// navigate down the tree to short-circuit as many as possible of the keys gt from
+		direction = from.direction(n.critbyte, n.critbit)
+	}
+
+	var result bool
+	if direction == 1 {
+		if result, needsCompare = n.children[1].visitDescend(from, visitor, needsCompare); !result {
+			return false, needsCompare
+		}
+		// if the direction we chose at this node was one, then the 0 child
+		// is lt the key so we can start short-circuting the comparisons.
+		needsCompare = false
+	}
+	if result, needsCompare = n.children[0].visitDescend(from, visitor, needsCompare); !result {
+		return false, needsCompare
+	}
+	return true, needsCompare
+}
+
+/*
+VisitRange applies the visitor function in ascending order to the key-value pairs whose keys
+fall between @from and @to.  Each bound may be inclusive or exclusive, and a nil bound leaves
+that end of the range open.  The visitor's boolean return value indicates whether to continue.
+Subtrees falling entirely outside the range are skipped without visiting them, ex:
+
+	//get every word between "bag" and "boo", excluding "boo" itself
+	myDictionary.VisitRange([]byte("bag"), true, []byte("boo"), false, func(key []byte, val interface{}) bool {
+		words = append(words, string(key))
+		return true
+	})
+	words == []string{"bag", "barn", "beetlejuice"}
+*/
+func (t *TypedTrie[V]) VisitRange(from []byte, fromInclusive bool, to []byte, toInclusive bool, visitor func([]byte, V) bool) {
+	if t.root == nil {
+		return
+	}
+	r := &keyRange{fromInclusive: fromInclusive, toInclusive: toInclusive}
+	if from != nil {
+		r.from = findBound(t.root, from)
+	}
+	if to != nil {
+		r.to = findBound(t.root, to)
+	}
+	t.root.visitRange(r, visitor, r.from != nil, r.to != nil)
+}
+
+type keyRange struct {
+	from, to                   *bound
+	fromInclusive, toInclusive bool
+}
+
+func (l *leaf[V]) visitRange(r *keyRange, visitor func([]byte, V) bool, needsFrom, needsTo bool) bool {
+	if needsTo {
+		c := bytes.Compare(l.key, r.to.key)
+		if c > 0 || (c == 0 && !r.toInclusive) {
+			// every following key is past the upper bound too
+			return false
+		}
+	}
+	if needsFrom {
+		c := bytes.Compare(l.key, r.from.key)
+		if c < 0 || (c == 0 && !r.fromInclusive) {
+			return true
+		}
+	}
+	return visitor(l.key, l.value)
+}
+
+func (n *inner[V]) visitRange(r *keyRange, visitor func([]byte, V) bool, needsFrom, needsTo bool) bool {
+	fromDirection, toDirection := 0, 1
+	if needsFrom {
+		fromDirection = r.from.direction(n.critbyte, n.critbit)
+	}
+	if needsTo {
+		toDirection = r.to.direction(n.critbyte, n.critbit)
+	}
+
+	// a 1 direction for the lower bound means the whole 0 child is lt the range,
+	// and a 0 direction for the upper bound means the whole 1 child is gt the range.
+	if fromDirection == 0 {
+		if !n.children[0].visitRange(r, visitor, needsFrom, needsTo && toDirection == 0) {
+			return false
+		}
+	}
+	if toDirection == 0 {
+		return false
+	}
+	return n.children[1].visitRange(r, visitor, needsFrom && fromDirection == 1, needsTo)
+}
+
+/*
+VisitPrefix applies the visitor function in ascending order to all key-value pairs whose keys
+begin with @prefix.  The visitor's boolean return value indicates whether to continue.
+Finding the keys takes O(len(prefix)) before visiting them, ex:
+
+	//get every word in our dictionary trie that starts with "bo"
+	myDictionary.VisitPrefix([]byte("bo"), func(key []byte, val interface{}) bool {
+		words = append(words, string(key))
+		return true
+	})
+	words == []string{"boo", "booger", "boogie"}
+*/
+func (t *TypedTrie[V]) VisitPrefix(prefix []byte, visitor func([]byte, V) bool) {
+	if t.root == nil {
+		return
+	}
+	if n := t.root.findPrefix(prefix); n != nil {
+		n.visitAscend(nil, visitor, false)
+	}
+}
+
+// Gets the number of keys in the trie which begin with @prefix, without visiting them.
+func (t *TypedTrie[V]) CountPrefix(prefix []byte) uint32 {
+	if t.root == nil {
+		return 0
+	}
+	if n := t.root.findPrefix(prefix); n != nil {
+		return n.size()
+	}
+	return 0
+}
+
+/*
+LongestPrefix gets the longest key in the trie which is a prefix of @key, including @key itself,
+along with its value.  Returns false if no key in the trie is a prefix of @key.  This takes one
+walk down the trie, ex:
+
+	//route a request path
+	routes.LongestPrefix([]byte("/api/users/42")) == []byte("/api/users"), usersHandler, true
+*/
+func (t *TypedTrie[V]) LongestPrefix(key []byte) ([]byte, V, bool) {
+	var longest *leaf[V]
+	visitPrefixesOf(t.root, key, func(l *leaf[V]) bool {
+		longest = l
+		return true
+	})
+	return leafResult(longest)
+}
+
+// Applies the visitor function to every key in the trie which is a prefix of @key, including
+// @key itself, from shortest to longest.  The visitor's boolean return value indicates whether
+// to continue.
+func (t *TypedTrie[V]) VisitPrefixesOf(key []byte, visitor func([]byte, V) bool) {
+	visitPrefixesOf(t.root, key, func(l *leaf[V]) bool {
+		return visitor(l.key, l.value)
+	})
+}
+
+// Finds the keys which are prefixes of @key.  A shorter key splits off from a longer key that
+// it prefixes at a length node, where the shorter key is the 0 child.  So the prefixes are all
+// either 0 children of length nodes on the key's path, or the leaf at the end of it.
+func visitPrefixesOf[V any](n node[V], key []byte, visitor func(*leaf[V]) bool) {
+	if n == nil {
+		return
+	}
+	in, ok := n.(*inner[V])
+	for ; ok; in, ok = n.(*inner[V]) {
+		direction := findDirection(key, in.critbyte, in.critbit)
+		if short, isLeaf := in.children[0].(*leaf[V]); in.critbit == 255 && direction == 1 &&
+			isLeaf && bytes.HasPrefix(key, short.key) {
+			if !visitor(short) {
+				return
+			}
+		}
+		n = in.children[direction]
+	}
+	if l := n.(*leaf[V]); bytes.HasPrefix(key, l.key) {
+		visitor(l)
+	}
+}
+
 //-- write operations --//
 
 // Returns a new Trie with the given key set to the given value.
-func (t *Trie) Set(key []byte, value interface{}) (*Trie, interface{}) {
-	if value == nil {
+func (t *TypedTrie[V]) Set(key []byte, value V) (*TypedTrie[V], V) {
+	var zero V
+	if any(value) == nil {
 		panic("value cannot be nil")
 	}
 
 	if t.root == nil {
-		return &Trie{
-			root: &node{
+		return &TypedTrie[V]{
+			root: &leaf[V]{
 				key:   key,
 				value: value,
-				count: 1,
 			},
-		}, nil
+			hash: t.hash.derive(),
+		}, zero
 	}
 
 	n := t.root.findBestLeaf(key)
 	if bytes.Equal(key, n.key) {
-		return &Trie{
+		return &TypedTrie[V]{
 			root: t.root.setLeaf(key, value),
+			hash: t.hash.derive(),
 		}, n.value
 	}
 
 	//insert node
 	critbyte, critbit := findCritbit(key, n.key)
-	return &Trie{
+	return &TypedTrie[V]{
 		root: t.root.insertLeaf(key, value, critbyte, critbit),
-	}, nil
+		hash: t.hash.derive(),
+	}, zero
 }
 
 // Deletes the key-value pair for the given key out of the trie.
-func (t *Trie) Delete(key []byte) (*Trie, interface{}) {
+func (t *TypedTrie[V]) Delete(key []byte) (*TypedTrie[V], V) {
+	var zero V
 	if t.root == nil {
-		return t, nil
+		return t, zero
 	}
 
 	n := t.root.findBestLeaf(key)
 	if bytes.Equal(key, n.key) {
-		return &Trie{
+		return &TypedTrie[V]{
 			root: t.root.deleteLeaf(key),
+			hash: t.hash.derive(),
 		}, n.value
 	}
 
-	return t, nil
+	return t, zero
 }
 
 //-- internal functions --//
 
-func (n *node) findBestLeaf(key []byte) *node {
-	if n.key != nil {
-		//it's a leaf - return it
-		return n
+func (l *leaf[V]) findBestLeaf(key []byte) *leaf[V] {
+	return l
+}
+
+func (n *inner[V]) findBestLeaf(key []byte) *leaf[V] {
+	// walk down in a loop rather than recursing, since this is on the path of every operation.
+	child := n.children[findDirection(key, n.critbyte, n.critbit)]
+	for {
+		in, ok := child.(*inner[V])
+		if !ok {
+			//it's a leaf - return it
+			return child.(*leaf[V])
+		}
+		child = in.children[findDirection(key, in.critbyte, in.critbit)]
 	}
+}
 
-	direction := findDirection(key, n.critbyte, n.critbit)
-	return n.children[direction].findBestLeaf(key)
+// Finds the root of the subtree containing every key with the given prefix, or nil if there are none.
+func (l *leaf[V]) findPrefix(prefix []byte) node[V] {
+	if !bytes.HasPrefix(l.key, prefix) {
+		return nil
+	}
+	return l
 }
 
-func (n *node) setLeaf(key []byte, value interface{}) *node {
-	if n.key != nil {
-		//it's the leaf - set it
-		return &node{
-			key:   key,
-			value: value,
-			count: 1,
-		}
+func (n *inner[V]) findPrefix(prefix []byte) node[V] {
+	if n.critbyte < len(prefix)-1 || (n.critbyte == len(prefix)-1 && n.critbit != 255) {
+		//the critical bit is inside the prefix, keep navigating
+		direction := findDirection(prefix, n.critbyte, n.critbit)
+		return n.children[direction].findPrefix(prefix)
+	}
+
+	//all keys below this node agree up to the end of the prefix, so checking any one is enough.
+	if !bytes.HasPrefix(n.edge(0).key, prefix) {
+		return nil
 	}
+	return n
+}
 
+func (l *leaf[V]) setLeaf(key []byte, value V) node[V] {
+	//it's the leaf - set it
+	return &leaf[V]{
+		key:   key,
+		value: value,
+	}
+}
+
+func (n *inner[V]) setLeaf(key []byte, value V) node[V] {
 	//walk the tree, and create a new node to return pointing to our new deep child.
 	direction := findDirection(key, n.critbyte, n.critbit)
-	ret := &node{
+	ret := &inner[V]{
 		critbit:  n.critbit,
 		critbyte: n.critbyte,
 	}
@@ -194,29 +499,34 @@ func (n *node) setLeaf(key []byte, value interface{}) *node {
 	return ret
 }
 
-func (n *node) insertLeaf(key []byte, value interface{}, critbyte int, critbit uint8) *node {
-	if n.key != nil ||
-		n.critbyte > critbyte || (n.critbyte == critbyte && n.critbit > critbit) {
-		//this is the leaf we calculated the critbit from OR
-		//this node's critbit is bigger than the one we're trying to add, add a node before it
-		dir := findDirection(key, critbyte, critbit)
-		ret := &node{
-			critbyte: critbyte,
-			critbit:  critbit,
-			count:    n.count + 1,
-		}
-		ret.children[dir] = &node{
-			key:   key,
-			value: value,
-			count: 1,
-		}
-		ret.children[1-dir] = n
-		return ret
+// Adds a node before this one, joining it with a new leaf.  This is where a new leaf goes
+// when this is the leaf we calculated the critbit from, or this node's critbit is bigger
+// than the one we're trying to add.
+func insertAbove[V any](n node[V], l *leaf[V], critbyte int, critbit uint8, owned bool) node[V] {
+	dir := findDirection(l.key, critbyte, critbit)
+	ret := &inner[V]{
+		critbyte: critbyte,
+		critbit:  critbit,
+		owned:    owned,
+		count:    n.size() + 1,
+	}
+	ret.children[dir] = l
+	ret.children[1-dir] = n
+	return ret
+}
+
+func (l *leaf[V]) insertLeaf(key []byte, value V, critbyte int, critbit uint8) node[V] {
+	return insertAbove[V](l, &leaf[V]{key: key, value: value}, critbyte, critbit, false)
+}
+
+func (n *inner[V]) insertLeaf(key []byte, value V, critbyte int, critbit uint8) node[V] {
+	if n.critbyte > critbyte || (n.critbyte == critbyte && n.critbit > critbit) {
+		return insertAbove[V](n, &leaf[V]{key: key, value: value}, critbyte, critbit, false)
 	}
 
 	//this node's critbit is smaller than the one we're trying to add, insert after it
 	dir := findDirection(key, n.critbyte, n.critbit)
-	ret := &node{
+	ret := &inner[V]{
 		critbyte: n.critbyte,
 		critbit:  n.critbit,
 		count:    n.count + 1,
@@ -226,13 +536,12 @@ func (n *node) insertLeaf(key []byte, value interface{}, critbyte int, critbit u
 	return ret
 }
 
-func (n *node) deleteLeaf(key []byte) *node {
-
-	if n.key != nil {
-		//this is the expected leaf delete it by returning nil
-		return nil
-	}
+func (l *leaf[V]) deleteLeaf(key []byte) node[V] {
+	//this is the expected leaf delete it by returning nil
+	return nil
+}
 
+func (n *inner[V]) deleteLeaf(key []byte) node[V] {
 	dir := findDirection(key, n.critbyte, n.critbit)
 	result := n.children[dir].deleteLeaf(key)
 	if result == nil {
@@ -241,7 +550,7 @@ func (n *node) deleteLeaf(key []byte) *node {
 	}
 
 	//update the child in this node
-	ret := &node{
+	ret := &inner[V]{
 		critbyte: n.critbyte,
 		critbit:  n.critbit,
 		count:    n.count - 1,
@@ -252,6 +561,55 @@ func (n *node) deleteLeaf(key []byte) *node {
 
 }
 
+// A bound is a key which may or may not exist in the trie, positioned relative to the trie's nodes.
+type bound struct {
+	key []byte
+	// the critical bit where the key diverges from every key in the trie.
+	critbyte int
+	critbit  uint8
+	// the direction the key takes at its critical bit, or -1 if the key exists in the trie.
+	dir int
+}
+
+func findBound[V any](n node[V], key []byte) *bound {
+	best := n.findBestLeaf(key)
+	if bytes.Equal(key, best.key) {
+		return &bound{key: key, dir: -1}
+	}
+	critbyte, critbit := findCritbit(key, best.key)
+	return &bound{
+		key:      key,
+		critbyte: critbyte,
+		critbit:  critbit,
+		dir:      findDirection(key, critbyte, critbit),
+	}
+}
+
+// Gets the direction to navigate at a node in order to reach the bound.  Below the bound's
+// critical bit, the subtree's keys are all on one side of the bound regardless of the bits
+// in the key itself.
+func (b *bound) direction(critbyte int, critbit uint8) int {
+	if b.dir >= 0 && (critbyte > b.critbyte || (critbyte == b.critbyte && critbit > b.critbit)) {
+		return b.dir
+	}
+	return findDirection(b.key, critbyte, critbit)
+}
+
+// Gets the position of this node's critical bit.  Leaves are positioned after every internal node.
+func (l *leaf[V]) position() (int, uint8) {
+	return math.MaxInt, 255
+}
+
+func (n *inner[V]) position() (int, uint8) {
+	return n.critbyte, n.critbit
+}
+
+// Returns true if the critical bit at position a comes before the one at position b, meaning
+// a node at a belongs higher in the tree.
+func positionLess(critbyteA int, critbitA uint8, critbyteB int, critbitB uint8) bool {
+	return critbyteA < critbyteB || (critbyteA == critbyteB && critbitA < critbitB)
+}
+
 func findDirection(key []byte, critbyte int, critbit uint8) int {
 	if critbit == 255 {
 		//special case - length comparison.  Longer keys are 1, shorter are 0.
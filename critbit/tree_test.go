@@ -22,8 +22,8 @@ func TestSet_Insert_Root(t *testing.T) {
 	assert.NotEqual(t, result, instance, "result should be new list")
 	assert.Equal(t, 1, result.Len(), "result should have length 1")
 	assert.NotNil(t, result.root, "result should have a root node")
-	assert.Equal(t, []byte{0x01, 0x02, 0x03}, result.root.key, "root node should be the leaf")
-	assert.Equal(t, 123, result.root.value, "root node should be the leaf")
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, asLeaf(result.root).key, "root node should be the leaf")
+	assert.Equal(t, 123, asLeaf(result.root).value, "root node should be the leaf")
 
 	assert.Equal(t, 0, instance.Len(), "original tree should remain immutable")
 	assert.Nil(t, instance.root, "original tree should remain immutable")
@@ -39,14 +39,14 @@ func TestSet_Insert_SecondNodeAtZero(t *testing.T) {
 	assert.Nil(t, old, "nothing should be overwritten")
 	assert.NotEqual(t, result, instance, "result should be new list")
 	assert.Equal(t, 2, result.Len(), "result should have length 1")
-	assert.Nil(t, result.root.key, "root node should be a critbit node")
-	assert.Equal(t, 2, result.root.critbyte, "root critbyte")
-	assert.Equal(t, 0xFE, result.root.critbit, "root critbit")
-	assert.Equal(t, []byte{0x01, 0x02, 0x03}, result.root.children[1].key, "first child key")
-	assert.Equal(t, []byte{0x01, 0x02, 0x02}, result.root.children[0].key, "zero child key")
+	require.NotNil(t, asInner(result.root), "root node should be a critbit node")
+	assert.Equal(t, 2, asInner(result.root).critbyte, "root critbyte")
+	assert.Equal(t, 0xFE, asInner(result.root).critbit, "root critbit")
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, asLeaf(asInner(result.root).children[1]).key, "first child key")
+	assert.Equal(t, []byte{0x01, 0x02, 0x02}, asLeaf(asInner(result.root).children[0]).key, "zero child key")
 
 	assert.Equal(t, 1, instance.Len(), "original tree should remain immutable")
-	assert.Equal(t, []byte{0x01, 0x02, 0x03}, instance.root.key, "original tree should remain immutable")
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, asLeaf(instance.root).key, "original tree should remain immutable")
 }
 
 func TestSet_Insert_SecondNodeAtOne(t *testing.T) {
@@ -59,14 +59,14 @@ func TestSet_Insert_SecondNodeAtOne(t *testing.T) {
 	assert.Nil(t, old, "nothing should be overwritten")
 	assert.NotEqual(t, result, instance, "result should be new list")
 	assert.Equal(t, 2, result.Len(), "result should have length 1")
-	assert.Nil(t, result.root.key, "root node should be a critbit node")
-	assert.Equal(t, 1, result.root.critbyte, "root critbyte")
-	assert.Equal(t, 0xFB, result.root.critbit, "root critbit")
-	assert.Equal(t, []byte{0x01, 0x04, 0x02}, result.root.children[1].key, "first child key")
-	assert.Equal(t, []byte{0x01, 0x02, 0x03}, result.root.children[0].key, "zero child key")
+	require.NotNil(t, asInner(result.root), "root node should be a critbit node")
+	assert.Equal(t, 1, asInner(result.root).critbyte, "root critbyte")
+	assert.Equal(t, 0xFB, asInner(result.root).critbit, "root critbit")
+	assert.Equal(t, []byte{0x01, 0x04, 0x02}, asLeaf(asInner(result.root).children[1]).key, "first child key")
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, asLeaf(asInner(result.root).children[0]).key, "zero child key")
 
 	assert.Equal(t, 1, instance.Len(), "original tree should remain immutable")
-	assert.Equal(t, []byte{0x01, 0x02, 0x03}, instance.root.key, "original tree should remain immutable")
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, asLeaf(instance.root).key, "original tree should remain immutable")
 }
 
 func TestSet_Insert_ThirdNodeBeforeNode(t *testing.T) {
@@ -80,11 +80,11 @@ func TestSet_Insert_ThirdNodeBeforeNode(t *testing.T) {
 	assert.Nil(t, old, "nothing should be overwritten")
 	assert.NotEqual(t, result, instance, "result should be new list")
 	assert.Equal(t, 3, result.Len(), "result should have length 1")
-	assert.Nil(t, result.root.key, "root node should be a critbit node")
-	assert.Equal(t, 1, result.root.critbyte, "root critbyte")
-	assert.Equal(t, 0x7F, result.root.critbit, "root critbit")
-	assert.Equal(t, []byte{0x01, 0x80, 0x02}, result.root.children[1].key, "first child key")
-	assert.Nil(t, result.root.children[0].key, "zero child should be node")
+	require.NotNil(t, asInner(result.root), "root node should be a critbit node")
+	assert.Equal(t, 1, asInner(result.root).critbyte, "root critbyte")
+	assert.Equal(t, 0x7F, asInner(result.root).critbit, "root critbit")
+	assert.Equal(t, []byte{0x01, 0x80, 0x02}, asLeaf(asInner(result.root).children[1]).key, "first child key")
+	assert.NotNil(t, asInner(asInner(result.root).children[0]), "zero child should be node")
 
 }
 
@@ -99,13 +99,13 @@ func TestSet_Insert_ThirdNodeAfterNode(t *testing.T) {
 	assert.Nil(t, old, "nothing should be overwritten")
 	assert.NotEqual(t, result, instance, "result should be new list")
 	assert.Equal(t, 3, result.Len(), "result should have length 1")
-	assert.Nil(t, result.root.key, "root node should be a critbit node")
-	assert.Equal(t, 1, result.root.critbyte, "root critbyte")
-	assert.Equal(t, 0xFD, result.root.critbit, "root critbit")
-	assert.Equal(t, []byte{0x01, 0x01, 0x04}, result.root.children[0].key, "0 child key")
-	assert.Nil(t, result.root.children[1].key, "1 child should be node")
-	assert.Equal(t, 2, result.root.children[1].critbyte, "child critbyte")
-	assert.Equal(t, 0xFE, result.root.children[1].critbit, "child critbit")
+	require.NotNil(t, asInner(result.root), "root node should be a critbit node")
+	assert.Equal(t, 1, asInner(result.root).critbyte, "root critbyte")
+	assert.Equal(t, 0xFD, asInner(result.root).critbit, "root critbit")
+	assert.Equal(t, []byte{0x01, 0x01, 0x04}, asLeaf(asInner(result.root).children[0]).key, "0 child key")
+	assert.NotNil(t, asInner(asInner(result.root).children[1]), "1 child should be node")
+	assert.Equal(t, 2, asInner(asInner(result.root).children[1]).critbyte, "child critbyte")
+	assert.Equal(t, 0xFE, asInner(asInner(result.root).children[1]).critbit, "child critbit")
 
 }
 
@@ -131,7 +131,7 @@ func TestSet_OverwriteRoot_ReturnsOriginal(t *testing.T) {
 	//assert
 	assert.Equal(t, 123, old.(int), "old")
 	assert.NotEqual(t, instance, result, "should return new tree")
-	assert.Equal(t, 124, result.root.value.(int))
+	assert.Equal(t, 124, asLeaf(result.root).value.(int))
 	assert.Equal(t, 1, result.Len(), "len")
 }
 
@@ -146,7 +146,7 @@ func TestSet_OverwriteDeep_ReturnsOriginal(t *testing.T) {
 	//assert
 	assert.Equal(t, 123, old.(int), "old")
 	assert.NotEqual(t, instance, result, "should return new tree")
-	assert.Equal(t, 124, result.root.children[1].children[1].value.(int))
+	assert.Equal(t, 124, asLeaf(asInner(asInner(result.root).children[1]).children[1]).value.(int))
 	assert.Equal(t, 3, result.Len(), "len")
 }
 
@@ -159,8 +159,8 @@ func TestSet_Prefix_CreatesSpecialCaseNode(t *testing.T) {
 	//assert
 	assert.Nil(t, old, "old")
 	assert.NotEqual(t, instance, result, "should return new tree")
-	assert.Equal(t, 12, result.root.children[0].value.(int))
-	assert.Equal(t, 123, result.root.children[1].value.(int))
+	assert.Equal(t, 12, asLeaf(asInner(result.root).children[0]).value.(int))
+	assert.Equal(t, 123, asLeaf(asInner(result.root).children[1]).value.(int))
 	assert.Equal(t, 2, result.Len(), "len")
 }
 
@@ -173,8 +173,8 @@ func TestSet_Suffix_CreatesSpecialCaseNode(t *testing.T) {
 	//assert
 	assert.Nil(t, old, "old")
 	assert.NotEqual(t, instance, result, "should return new tree")
-	assert.Equal(t, 123, result.root.children[0].value.(int))
-	assert.Equal(t, 1234, result.root.children[1].value.(int))
+	assert.Equal(t, 123, asLeaf(asInner(result.root).children[0]).value.(int))
+	assert.Equal(t, 1234, asLeaf(asInner(result.root).children[1]).value.(int))
 	assert.Equal(t, 2, result.Len(), "len")
 }
 
@@ -362,8 +362,8 @@ func TestDelete_Deep_Success(t *testing.T) {
 	assert.True(t, ok, "expect immutability")
 
 	//node structure
-	assert.Equal(t, 123, result.root.children[0].value.(int), "123")
-	assert.Equal(t, 133, result.root.children[1].value.(int), "133")
+	assert.Equal(t, 123, asLeaf(asInner(result.root).children[0]).value.(int), "123")
+	assert.Equal(t, 133, asLeaf(asInner(result.root).children[1]).value.(int), "133")
 }
 
 func TestDelete_Deep_Failure(t *testing.T) {
@@ -397,7 +397,7 @@ func TestDelete_Prefix_Success(t *testing.T) {
 	assert.True(t, ok, "expect immutability")
 
 	//node structure
-	assert.Equal(t, 123, result.root.value.(int), "123")
+	assert.Equal(t, 123, asLeaf(result.root).value.(int), "123")
 }
 
 func TestDelete_Suffix_Success(t *testing.T) {
@@ -418,7 +418,58 @@ func TestDelete_Suffix_Success(t *testing.T) {
 	assert.True(t, ok, "expect immutability")
 
 	//node structure
-	assert.Equal(t, 12, result.root.value.(int), "123")
+	assert.Equal(t, 12, asLeaf(result.root).value.(int), "123")
+}
+
+func TestTypedTrie_SetGetDelete(t *testing.T) {
+	instance, old := NilTypedTrie[int]().Set([]byte{0x01, 0x02, 0x03}, 123)
+	assert.Equal(t, 0, old, "nothing should be overwritten")
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 124)
+
+	//act
+	result, was := instance.Delete([]byte{0x01, 0x02, 0x03})
+
+	//assert
+	assert.Equal(t, 123, was, "should return old value")
+	got, ok := result.Get([]byte{0x01, 0x02, 0x04})
+	require.True(t, ok)
+	assert.Equal(t, 124, got)
+	got, ok = result.Get([]byte{0x01, 0x02, 0x03})
+	assert.False(t, ok)
+	assert.Equal(t, 0, got, "should return zero value")
+	assert.Equal(t, 1, result.Len(), "len")
+	assert.Equal(t, 2, instance.Len(), "expect immutability")
+}
+
+func TestTypedTrie_ZeroValueIsEmpty(t *testing.T) {
+	var instance TypedTrie[string]
+
+	//act
+	result, old := instance.Set([]byte("a"), "")
+
+	//assert
+	assert.Equal(t, "", old)
+	got, ok := result.Get([]byte("a"))
+	assert.True(t, ok, "zero values may be stored")
+	assert.Equal(t, "", got)
+	assert.Equal(t, 0, instance.Len(), "original tree should remain immutable")
+}
+
+func TestTypedTrie_NilInterfaceValue_Panics(t *testing.T) {
+	instance := NilTypedTrie[error]()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			assert.Fail(t, "should have panicked")
+		}
+	}()
+
+	//act
+	_, _ = instance.Set([]byte{0x01}, nil)
+
+	//assert
+	assert.Fail(t, "should have panicked")
 }
 
 //-- Internal functions --//
@@ -614,29 +665,40 @@ func TestInsert_RandomBytes_DoesNotFail(t *testing.T) {
 	}
 }
 
-func (t *Trie) DumpTrie() string {
+func (t *TypedTrie[V]) DumpTrie() string {
 	if t.root == nil {
 		return "empty"
 	}
-	return t.root.DumpNode("")
+	return dumpNode(t.root, "")
 }
 
-func (n *node) DumpNode(prefix string) string {
-	keyStr := ""
-	if utf8.Valid(n.key) {
-		keyStr = string(n.key)
-	} else {
-		keyStr = fmt.Sprintf("[%x]", n.key)
+func dumpNode[V any](n node[V], prefix string) string {
+	in, ok := n.(*inner[V])
+	if !ok {
+		key := n.(*leaf[V]).key
+		if utf8.Valid(key) {
+			return string(key) + "\n"
+		}
+		return fmt.Sprintf("[%x]\n", key)
 	}
-	head := fmt.Sprintf("%s (%d %x)\n", keyStr, n.critbyte, n.critbit)
+	head := fmt.Sprintf("(%d %x)\n", in.critbyte, in.critbit)
 
 	p2 := prefix + "  "
-	if n.children[0] != nil {
-		ch0 := fmt.Sprintf("%s[0]: %s", p2, n.children[0].DumpNode(p2))
-		ch1 := fmt.Sprintf("%s[1]: %s", p2, n.children[1].DumpNode(p2))
-		return head + ch0 + ch1
-	}
-	return head
+	ch0 := fmt.Sprintf("%s[0]: %s", p2, dumpNode(in.children[0], p2))
+	ch1 := fmt.Sprintf("%s[1]: %s", p2, dumpNode(in.children[1], p2))
+	return head + ch0 + ch1
+}
+
+// gets the node as a leaf, or nil if it is an inner node.
+func asLeaf(n node[interface{}]) *leaf[interface{}] {
+	l, _ := n.(*leaf[interface{}])
+	return l
+}
+
+// gets the node as an inner node, or nil if it is a leaf.
+func asInner(n node[interface{}]) *inner[interface{}] {
+	in, _ := n.(*inner[interface{}])
+	return in
 }
 
 func randBytes() []byte {
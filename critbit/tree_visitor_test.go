@@ -3,6 +3,8 @@ package critbit
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -234,6 +236,464 @@ func TestVisitAscend_FromPrefixOfPrefix(t *testing.T){
 	assert.Equal(t, "abcdefgh/abcd3", string(keys[3]))
 }
 
+func TestVisitAscend_TypedTrie(t *testing.T) {
+	instance, _ := NilTypedTrie[int]().Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("c"), 3)
+
+	//act
+	sum := 0
+	vals := make([]int, 0, 2)
+	instance.VisitAscend([]byte("b"), func(key []byte, val int) bool {
+		sum += val
+		vals = append(vals, val)
+		return true
+	})
+
+	//assert
+	require.Equal(t, 2, len(vals), "len")
+	assert.Equal(t, []int{2, 3}, vals)
+	assert.Equal(t, 5, sum)
+}
+
+func TestVisitAscend_FromDivergesAboveNode(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ba"), 1)
+	instance, _ = instance.Set([]byte("bc"), 2)
+
+	//act
+	// 0x02 has the critical bit between "ba" and "bc" set, but "a" sorts before both.
+	keys := visitToSlice(instance, []byte("a\x02"))
+
+	//assert
+	require.Equal(t, 2, len(keys), "len")
+	assert.Equal(t, "ba", string(keys[0]))
+	assert.Equal(t, "bc", string(keys[1]))
+}
+
+func TestVisitAscend_RandomFrom_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i := 0; i < 100; i++ {
+		from := randBytes()
+
+		//act
+		keys := visitToSlice(instance, from)
+
+		//assert
+		start := sort.SearchStrings(sorted, string(from))
+		require.Equal(t, len(sorted)-start, len(keys), "len from %x", from)
+		for j, k := range keys {
+			assert.Equal(t, sorted[start+j], string(k))
+		}
+	}
+}
+
+func TestVisitDescend_NilTrie(t *testing.T) {
+
+	//act
+	keys := visitDescendToSlice(NilTrie(), []byte{0x01})
+
+	//assert
+	require.Equal(t, 0, len(keys), "len")
+}
+
+func TestVisitDescend_Root_SkipsGreaterThanFrom(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+
+	//act
+	keys := visitDescendToSlice(instance, []byte{0x01})
+
+	//assert
+	require.Equal(t, 0, len(keys), "len")
+}
+
+func TestVisitDescend_Root_DoesntSkipLessThanFrom(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+
+	//act
+	keys := visitDescendToSlice(instance, []byte{0x02})
+
+	//assert
+	require.Equal(t, 1, len(keys), "len")
+}
+
+func TestVisitDescend_ThirdNodeBeforeNode(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x80, 0x02}, 182)
+
+	//act
+	keys := visitDescendToSlice(instance, nil)
+
+	//assert
+	require.Equal(t, 3, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x80, 0x02}, keys[0])
+	assert.Equal(t, []byte{0x01, 0x02, 0x04}, keys[1])
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, keys[2])
+}
+
+func TestVisitDescend_FromIsInclusive(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 124)
+	instance, _ = instance.Set([]byte{0x01, 0x80, 0x02}, 182)
+
+	//act
+	keys := visitDescendToSlice(instance, []byte{0x01, 0x02, 0x04})
+
+	//assert
+	require.Equal(t, 2, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x02, 0x04}, keys[0])
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, keys[1])
+}
+
+func TestVisitDescend_PrefixSpecialCaseNode(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02}, 12)
+
+	//act
+	keys := visitDescendToSlice(instance, []byte{0x01, 0x02, 0x00})
+
+	//assert
+	require.Equal(t, 1, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x02}, keys[0])
+}
+
+func TestVisitDescend_StopBeforeEnd(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03, 0x04}, 1234)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x03, 0x05}, 1235)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x03, 0x06}, 1236)
+
+	//act
+	vals := make([]interface{}, 0, 2)
+	instance.VisitDescend(nil, func(key []byte, val interface{}) bool {
+		vals = append(vals, val)
+		if bytes.Equal(key, []byte{0x01, 0x02, 0x03, 0x05}) {
+			return false
+		}
+		return true
+	})
+
+	//assert
+	require.Equal(t, 2, len(vals), "len")
+	assert.Equal(t, 1236, vals[0])
+	assert.Equal(t, 1235, vals[1])
+}
+
+func TestVisitDescend_RandomFrom_MatchesSorted(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i := 0; i < 100; i++ {
+		from := randBytes()
+
+		//act
+		keys := visitDescendToSlice(instance, from)
+
+		//assert
+		end := sort.Search(len(sorted), func(i int) bool { return sorted[i] > string(from) })
+		require.Equal(t, end, len(keys), "len from %x", from)
+		for j, k := range keys {
+			assert.Equal(t, sorted[end-1-j], string(k))
+		}
+	}
+}
+
+func TestVisitRange_NilTrie(t *testing.T) {
+
+	//act
+	keys := visitRangeToSlice(NilTrie(), []byte{0x01}, true, []byte{0x02}, true)
+
+	//assert
+	require.Equal(t, 0, len(keys), "len")
+}
+
+func TestVisitRange_InclusiveBounds(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 124)
+	instance, _ = instance.Set([]byte{0x01, 0x80, 0x02}, 182)
+	instance, _ = instance.Set([]byte{0x02}, 2)
+
+	//act
+	keys := visitRangeToSlice(instance, []byte{0x01, 0x02, 0x04}, true, []byte{0x01, 0x80, 0x02}, true)
+
+	//assert
+	require.Equal(t, 2, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x02, 0x04}, keys[0])
+	assert.Equal(t, []byte{0x01, 0x80, 0x02}, keys[1])
+}
+
+func TestVisitRange_ExclusiveBounds(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02, 0x04}, 124)
+	instance, _ = instance.Set([]byte{0x01, 0x80, 0x02}, 182)
+	instance, _ = instance.Set([]byte{0x02}, 2)
+
+	//act
+	keys := visitRangeToSlice(instance, []byte{0x01, 0x02, 0x03}, false, []byte{0x02}, false)
+
+	//assert
+	require.Equal(t, 2, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x02, 0x04}, keys[0])
+	assert.Equal(t, []byte{0x01, 0x80, 0x02}, keys[1])
+}
+
+func TestVisitRange_OpenEnds(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+	instance, _ = instance.Set([]byte("c"), 3)
+
+	//act
+	below := visitRangeToSlice(instance, nil, false, []byte("b"), false)
+	above := visitRangeToSlice(instance, []byte("b"), false, nil, false)
+
+	//assert
+	require.Equal(t, 1, len(below), "below len")
+	assert.Equal(t, "a", string(below[0]))
+	require.Equal(t, 1, len(above), "above len")
+	assert.Equal(t, "c", string(above[0]))
+}
+
+func TestVisitRange_EmptyRange(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("a"), 1)
+	instance, _ = instance.Set([]byte("b"), 2)
+
+	//act
+	keys := visitRangeToSlice(instance, []byte("b"), true, []byte("a"), true)
+
+	//assert
+	require.Equal(t, 0, len(keys), "len")
+}
+
+func TestVisitRange_StopBeforeEnd(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01}, 1)
+	instance, _ = instance.Set([]byte{0x02}, 2)
+	instance, _ = instance.Set([]byte{0x03}, 3)
+
+	//act
+	vals := make([]interface{}, 0, 1)
+	instance.VisitRange(nil, true, nil, true, func(key []byte, val interface{}) bool {
+		vals = append(vals, val)
+		return false
+	})
+
+	//assert
+	require.Equal(t, 1, len(vals), "len")
+	assert.Equal(t, 1, vals[0])
+}
+
+func TestVisitRange_Random_MatchesVisitAscendWithManualCheck(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i := 0; i < 200; i++ {
+		from, to := randBytes(), randBytes()
+		if i%4 == 0 {
+			// exercise the bounds on keys that exist in the trie
+			from, to = []byte(sorted[rand.Intn(len(sorted))]), []byte(sorted[rand.Intn(len(sorted))])
+		}
+		if bytes.Compare(from, to) > 0 {
+			from, to = to, from
+		}
+		fromInclusive, toInclusive := i%2 == 0, i%3 == 0
+
+		expected := make([][]byte, 0)
+		instance.VisitAscend(from, func(key []byte, val interface{}) bool {
+			if !fromInclusive && bytes.Equal(key, from) {
+				return true
+			}
+			c := bytes.Compare(key, to)
+			if c > 0 || (c == 0 && !toInclusive) {
+				return false
+			}
+			expected = append(expected, key)
+			return true
+		})
+
+		//act
+		keys := visitRangeToSlice(instance, from, fromInclusive, to, toInclusive)
+
+		//assert
+		require.Equal(t, len(expected), len(keys), "len from %x to %x", from, to)
+		for j := range keys {
+			assert.Equal(t, expected[j], keys[j])
+		}
+	}
+}
+
+func TestVisitPrefix_NilTrie(t *testing.T) {
+
+	//act
+	keys := visitPrefixToSlice(NilTrie(), []byte("a"))
+
+	//assert
+	require.Equal(t, 0, len(keys), "len")
+	assert.Equal(t, 0, NilTrie().CountPrefix([]byte("a")))
+}
+
+func TestVisitPrefix_Dictionary(t *testing.T) {
+	instance := NilTrie()
+	for _, w := range []string{"a", "b", "baaa", "bag", "barn", "beetlejuice", "boo", "booger", "boogie", "c", "cab"} {
+		instance, _ = instance.Set([]byte(w), w)
+	}
+
+	//act
+	keys := visitPrefixToSlice(instance, []byte("bo"))
+	count := instance.CountPrefix([]byte("bo"))
+
+	//assert
+	require.Equal(t, 3, len(keys), "len")
+	assert.Equal(t, "boo", string(keys[0]))
+	assert.Equal(t, "booger", string(keys[1]))
+	assert.Equal(t, "boogie", string(keys[2]))
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 8, instance.CountPrefix([]byte("b")), "b")
+	assert.Equal(t, 3, instance.CountPrefix([]byte("boo")), "boo")
+	assert.Equal(t, 2, instance.CountPrefix([]byte("boog")), "boog")
+	assert.Equal(t, 0, instance.CountPrefix([]byte("bz")), "bz")
+	assert.Equal(t, 0, instance.CountPrefix([]byte("boogies")), "boogies")
+	assert.Equal(t, 11, instance.CountPrefix(nil), "nil prefix")
+}
+
+func TestVisitPrefix_PrefixIsKey(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01, 0x02, 0x03}, 123)
+	instance, _ = instance.Set([]byte{0x01, 0x02}, 12)
+	instance, _ = instance.Set([]byte{0x01}, 1)
+
+	//act
+	keys := visitPrefixToSlice(instance, []byte{0x01, 0x02})
+
+	//assert
+	require.Equal(t, 2, len(keys), "len")
+	assert.Equal(t, []byte{0x01, 0x02}, keys[0])
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, keys[1])
+}
+
+func TestVisitPrefix_Random_MatchesHasPrefix(t *testing.T) {
+	instance, sorted := randomTrie(500)
+
+	for i := 0; i < 200; i++ {
+		prefix := randBytes()
+		if len(prefix) > 2 {
+			prefix = prefix[:i%3]
+		}
+		if i%2 == 0 {
+			// exercise prefixes of keys that exist in the trie
+			k := sorted[rand.Intn(len(sorted))]
+			prefix = []byte(k[:rand.Intn(len(k)+1)])
+		}
+
+		expected := make([]string, 0)
+		for _, k := range sorted {
+			if bytes.HasPrefix([]byte(k), prefix) {
+				expected = append(expected, k)
+			}
+		}
+
+		//act
+		keys := visitPrefixToSlice(instance, prefix)
+		count := instance.CountPrefix(prefix)
+
+		//assert
+		require.Equal(t, len(expected), len(keys), "len prefix %x", prefix)
+		assert.Equal(t, len(expected), count, "count prefix %x", prefix)
+		for j := range keys {
+			assert.Equal(t, expected[j], string(keys[j]))
+		}
+	}
+}
+
+func TestLongestPrefix_NilTrie(t *testing.T) {
+
+	//act
+	key, val, ok := NilTrie().LongestPrefix([]byte("abc"))
+
+	//assert
+	assert.False(t, ok)
+	assert.Nil(t, key)
+	assert.Nil(t, val)
+}
+
+func TestLongestPrefix_Routes(t *testing.T) {
+	instance := NilTrie()
+	for _, w := range []string{"", "/api", "/api/users", "/api/users/", "/apiary", "/b"} {
+		instance, _ = instance.Set([]byte(w), w)
+	}
+
+	//act
+	key, val, ok := instance.LongestPrefix([]byte("/api/users/42"))
+	prefixes := visitPrefixesOfToSlice(instance, []byte("/api/users/42"))
+
+	//assert
+	require.True(t, ok)
+	assert.Equal(t, "/api/users/", string(key))
+	assert.Equal(t, "/api/users/", val)
+	assert.Equal(t, []string{"", "/api", "/api/users", "/api/users/"}, prefixes)
+	exact, _, _ := instance.LongestPrefix([]byte("/apiary"))
+	assert.Equal(t, "/apiary", string(exact), "a key is its own prefix")
+	empty, _, ok := instance.LongestPrefix([]byte("/c"))
+	assert.True(t, ok)
+	assert.Equal(t, "", string(empty))
+}
+
+func TestLongestPrefix_NoMatch(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte("ab"), 1)
+	instance, _ = instance.Set([]byte("abc"), 2)
+
+	//act
+	_, _, ok := instance.LongestPrefix([]byte("a"))
+	_, _, ok2 := instance.LongestPrefix([]byte("b"))
+
+	//assert
+	assert.False(t, ok)
+	assert.False(t, ok2)
+}
+
+func TestLongestPrefix_ZeroBytes(t *testing.T) {
+	instance, _ := NilTrie().Set([]byte{0x01}, 1)
+	instance, _ = instance.Set([]byte{0x01, 0x00}, 2)
+	instance, _ = instance.Set([]byte{0x01, 0x00, 0x00, 0x01}, 3)
+
+	//act
+	prefixes := visitPrefixesOfToSlice(instance, []byte{0x01, 0x00, 0x00})
+
+	//assert
+	assert.Equal(t, []string{"\x01", "\x01\x00"}, prefixes)
+}
+
+func TestLongestPrefix_Random_MatchesHasPrefix(t *testing.T) {
+	instance, sorted := randomTrie(500)
+	// add some prefixes of the existing keys
+	for i := 0; i < 300; i++ {
+		k := sorted[rand.Intn(len(sorted))]
+		instance, _ = instance.Set([]byte(k[:rand.Intn(len(k)+1)]), i)
+	}
+	all := make([]string, 0)
+	for _, k := range visitToSlice(instance, nil) {
+		all = append(all, string(k))
+	}
+
+	for i := 0; i < 200; i++ {
+		key := randBytes()
+		if i%2 == 0 {
+			key = append([]byte(all[rand.Intn(len(all))]), key[:min(i%3, len(key))]...)
+		}
+		expected := make([]string, 0)
+		for _, k := range all {
+			if bytes.HasPrefix(key, []byte(k)) {
+				expected = append(expected, k)
+			}
+		}
+
+		//act
+		prefixes := visitPrefixesOfToSlice(instance, key)
+		longest, _, ok := instance.LongestPrefix(key)
+
+		//assert
+		require.Equal(t, expected, prefixes, "prefixes of %x", key)
+		if assert.Equal(t, len(expected) > 0, ok, "longest prefix of %x", key) && ok {
+			assert.Equal(t, expected[len(expected)-1], string(longest))
+		}
+	}
+}
+
 func visitToSlice(t *Trie, from []byte) [][]byte {
 	ret := make([][]byte, 0, t.Len())
 	t.VisitAscend(from, func(key []byte, val interface{}) bool {
@@ -242,3 +702,56 @@ func visitToSlice(t *Trie, from []byte) [][]byte {
 	})
 	return ret
 }
+
+func visitDescendToSlice(t *Trie, from []byte) [][]byte {
+	ret := make([][]byte, 0, t.Len())
+	t.VisitDescend(from, func(key []byte, val interface{}) bool {
+		ret = append(ret, key)
+		return true
+	})
+	return ret
+}
+
+func visitRangeToSlice(t *Trie, from []byte, fromInclusive bool, to []byte, toInclusive bool) [][]byte {
+	ret := make([][]byte, 0, t.Len())
+	t.VisitRange(from, fromInclusive, to, toInclusive, func(key []byte, val interface{}) bool {
+		ret = append(ret, key)
+		return true
+	})
+	return ret
+}
+
+func visitPrefixToSlice(t *Trie, prefix []byte) [][]byte {
+	ret := make([][]byte, 0, t.Len())
+	t.VisitPrefix(prefix, func(key []byte, val interface{}) bool {
+		ret = append(ret, key)
+		return true
+	})
+	return ret
+}
+
+func visitPrefixesOfToSlice(t *Trie, key []byte) []string {
+	ret := make([]string, 0)
+	t.VisitPrefixesOf(key, func(key []byte, val interface{}) bool {
+		ret = append(ret, string(key))
+		return true
+	})
+	return ret
+}
+
+// builds a trie of random keys, returning it along with its keys in sorted order.
+func randomTrie(number int) (*Trie, []string) {
+	tree := NilTrie()
+	seen := make(map[string]bool)
+	sorted := make([]string, 0, number)
+	for i := 0; i < number; i++ {
+		key := randBytes()
+		tree, _ = tree.Set(key, i)
+		if !seen[string(key)] {
+			seen[string(key)] = true
+			sorted = append(sorted, string(key))
+		}
+	}
+	sort.Strings(sorted)
+	return tree, sorted
+}
@@ -0,0 +1,25 @@
+package keys
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkEncode_Tuple(b *testing.B) {
+	when := time.Now()
+	buf := make([]byte, 0, 64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf, _ = Tuple{"orders", int64(i), when}.AppendTo(buf[:0])
+	}
+}
+
+func BenchmarkDecode_Tuple(b *testing.B) {
+	key, _ := Encode("orders", int64(42), time.Now())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Decode(key)
+	}
+}
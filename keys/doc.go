@@ -0,0 +1,27 @@
+/*
+Package keys encodes values into byte slices whose order under bytes.Compare matches the
+values' natural order, so they can be used as critbit keys and visited in a meaningful order.
+It supports integers, floats, strings, byte slices, bools and time.Time, and tuples of them.
+
+Each value starts with a tag byte for its type, so values of different types sort by type
+first, in the order: byte slices, strings, integers, floats, bools, times.  Integers of every
+size, signed or not, share one ordering.  Strings and byte slices are terminated, so the
+encoding of a tuple is a prefix of the encoding of every longer tuple which starts with it.
+
+Examples:
+
+encoding a composite key -
+
+	key, err := keys.Encode("orders", int64(42), time.Now())
+
+visiting every key starting with ("orders", 42) -
+
+	prefix, err := keys.Encode("orders", int64(42))
+	trie.VisitPrefix(prefix, visitor)
+
+decoding a key -
+
+	tuple, err := keys.Decode(key)
+	//tuple == keys.Tuple{"orders", int64(42), time.Time{...}}
+*/
+package keys
@@ -0,0 +1,280 @@
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// A Tuple is a list of values which are encoded one after another.  Decoded tuples hold
+// []byte, string, int64, uint64 (only for integers too big for int64), float64, bool and
+// time.Time values.
+type Tuple []interface{}
+
+var (
+	errUnterminated = errors.New("keys: unterminated string")
+	errBadEscape    = errors.New("keys: invalid escape sequence in string")
+)
+
+// the bits of the one NaN which all NaNs are encoded as.
+const canonicalNaN = 0x7ff8000000000001
+
+// type tags, in the order the types sort in.
+const (
+	tagBytes       byte = 0x01
+	tagString      byte = 0x02
+	tagNegativeInt byte = 0x05
+	tagInt         byte = 0x06
+	tagFloat       byte = 0x07
+	tagBool        byte = 0x08
+	tagTime        byte = 0x09
+)
+
+// Encodes the values as a tuple.  Returns an error if a value's type is not supported.
+func Encode(values ...interface{}) ([]byte, error) {
+	return Tuple(values).AppendTo(nil)
+}
+
+// Encodes the tuple, appending it to @buf.  Returns an error if a value's type is not supported.
+func (t Tuple) AppendTo(buf []byte) ([]byte, error) {
+	for i, v := range t {
+		switch v := v.(type) {
+		case []byte:
+			buf = AppendBytes(buf, v)
+		case string:
+			buf = AppendString(buf, v)
+		case int:
+			buf = AppendInt(buf, int64(v))
+		case int8:
+			buf = AppendInt(buf, int64(v))
+		case int16:
+			buf = AppendInt(buf, int64(v))
+		case int32:
+			buf = AppendInt(buf, int64(v))
+		case int64:
+			buf = AppendInt(buf, v)
+		case uint:
+			buf = AppendUint(buf, uint64(v))
+		case uint8:
+			buf = AppendUint(buf, uint64(v))
+		case uint16:
+			buf = AppendUint(buf, uint64(v))
+		case uint32:
+			buf = AppendUint(buf, uint64(v))
+		case uint64:
+			buf = AppendUint(buf, v)
+		case float32:
+			buf = AppendFloat(buf, float64(v))
+		case float64:
+			buf = AppendFloat(buf, v)
+		case bool:
+			buf = AppendBool(buf, v)
+		case time.Time:
+			buf = AppendTime(buf, v)
+		default:
+			return nil, fmt.Errorf("keys: unsupported type %T at index %d", v, i)
+		}
+	}
+	return buf, nil
+}
+
+// Appends a byte slice.  The end is marked with 0x00, so 0x00 and 0x01 bytes are escaped as
+// 0x01 0x01 and 0x01 0x02.  That keeps the order, and means the marker never appears inside the
+// value, so an encoded tuple is never a prefix of a tuple with a different value in its place.
+func AppendBytes(buf []byte, v []byte) []byte {
+	return appendEscaped(append(buf, tagBytes), v)
+}
+
+// Appends a string, escaped in the same way as AppendBytes.
+func AppendString(buf []byte, v string) []byte {
+	return appendEscaped(append(buf, tagString), v)
+}
+
+func appendEscaped[S []byte | string](buf []byte, v S) []byte {
+	for i := 0; i < len(v); i++ {
+		if v[i] <= 0x01 {
+			buf = append(buf, 0x01, v[i]+1)
+		} else {
+			buf = append(buf, v[i])
+		}
+	}
+	return append(buf, 0x00)
+}
+
+// Appends a signed integer.  Negative integers are stored in 8 bytes with the sign bit flipped
+// so they sort below zero, and the rest are stored the same way as AppendUint.
+func AppendInt(buf []byte, v int64) []byte {
+	if v >= 0 {
+		return AppendUint(buf, uint64(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, tagNegativeInt), uint64(v)^(1<<63))
+}
+
+// Appends an unsigned integer in 8 big-endian bytes.
+func AppendUint(buf []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, tagInt), v)
+}
+
+// Appends a float in 8 bytes.  Negative numbers have all their bits flipped and positive
+// numbers just the sign bit, so that they sort numerically.  Every NaN is encoded as the same
+// quiet NaN, whatever its sign and payload, and sorts after +Inf.
+func AppendFloat(buf []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if v != v {
+		bits = canonicalNaN
+	}
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return binary.BigEndian.AppendUint64(append(buf, tagFloat), bits)
+}
+
+// Appends a bool, with false sorting before true.
+func AppendBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, tagBool, 1)
+	}
+	return append(buf, tagBool, 0)
+}
+
+// Appends a time as its seconds since the Unix epoch, stored like a signed integer, followed
+// by 4 bytes of nanoseconds.  The time zone is not kept, so times decode in UTC.
+func AppendTime(buf []byte, v time.Time) []byte {
+	buf = binary.BigEndian.AppendUint64(append(buf, tagTime), uint64(v.Unix())^(1<<63))
+	return binary.BigEndian.AppendUint32(buf, uint32(v.Nanosecond()))
+}
+
+// Decodes a key made by Encode back into its values.
+func Decode(key []byte) (Tuple, error) {
+	ret := make(Tuple, 0, 4)
+	for i := 0; i < len(key); {
+		tag := key[i]
+		start := i
+		i++
+		switch tag {
+		case tagBytes, tagString:
+			v, end, err := decodeEscaped(key, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			if tag == tagString {
+				ret = append(ret, string(v))
+			} else {
+				ret = append(ret, v)
+			}
+
+		case tagNegativeInt, tagInt, tagFloat, tagBool, tagTime:
+			size := fixedSize(tag)
+			if len(key)-i < size {
+				return nil, fmt.Errorf("keys: truncated %s at offset %d", typeName(tag), start)
+			}
+			data := key[i : i+size]
+			i += size
+			ret = append(ret, decodeFixed(tag, data))
+
+		default:
+			return nil, fmt.Errorf("keys: unknown type tag 0x%02x at offset %d", tag, start)
+		}
+	}
+	return ret, nil
+}
+
+// gets the number of bytes after the tag for fixed size types.
+func fixedSize(tag byte) int {
+	switch tag {
+	case tagBool:
+		return 1
+	case tagTime:
+		return 12
+	default:
+		return 8
+	}
+}
+
+func decodeFixed(tag byte, data []byte) interface{} {
+	switch tag {
+	case tagNegativeInt:
+		return int64(binary.BigEndian.Uint64(data) ^ (1 << 63))
+	case tagInt:
+		v := binary.BigEndian.Uint64(data)
+		if v > math.MaxInt64 {
+			return v
+		}
+		return int64(v)
+	case tagFloat:
+		bits := binary.BigEndian.Uint64(data)
+		if bits&(1<<63) != 0 {
+			bits &^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		return math.Float64frombits(bits)
+	case tagBool:
+		return data[0] != 0
+	default:
+		seconds := int64(binary.BigEndian.Uint64(data) ^ (1 << 63))
+		return time.Unix(seconds, int64(binary.BigEndian.Uint32(data[8:]))).UTC()
+	}
+}
+
+// Unescapes the terminated byte string which starts at @start in the key, after its tag.
+// Returns it and the offset after its terminator.
+func decodeEscaped(key []byte, start int) ([]byte, int, error) {
+	ret := make([]byte, 0, len(key)-start)
+	for i := start; i < len(key); i++ {
+		switch key[i] {
+		case 0x00:
+			return ret, i + 1, nil
+		case 0x01:
+			if i+1 >= len(key) || key[i+1] < 0x01 || key[i+1] > 0x02 {
+				return nil, 0, fmt.Errorf("%w at offset %d", errBadEscape, i)
+			}
+			ret = append(ret, key[i+1]-1)
+			i++
+		default:
+			ret = append(ret, key[i])
+		}
+	}
+	return nil, 0, fmt.Errorf("%w at offset %d", errUnterminated, start-1)
+}
+
+func typeName(tag byte) string {
+	switch tag {
+	case tagBytes:
+		return "bytes"
+	case tagString:
+		return "string"
+	case tagNegativeInt, tagInt:
+		return "integer"
+	case tagFloat:
+		return "float"
+	case tagBool:
+		return "bool"
+	default:
+		return "time"
+	}
+}
+
+/*
+PrefixEnd gets the smallest key which is greater than every key starting with @prefix, for use
+as the exclusive upper bound of a range scan.  Returns nil if there is no such key because the
+prefix is all 0xFF bytes, ex:
+
+	prefix, _ := keys.Encode("orders")
+	trie.VisitRange(prefix, true, keys.PrefixEnd(prefix), false, visitor)
+*/
+func PrefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			ret := append([]byte(nil), prefix[:i+1]...)
+			ret[i]++
+			return ret
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gburgett/immutable/critbit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checks that sorting the values by @less gives the same order as sorting their encodings.
+func assertOrderPreserved[T any](t *testing.T, values []T, less func(a, b T) bool) {
+	sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+	for i := 1; i < len(values); i++ {
+		a, err := Encode(values[i-1])
+		require.NoError(t, err)
+		b, err := Encode(values[i])
+		require.NoError(t, err)
+		if less(values[i-1], values[i]) {
+			assert.True(t, bytes.Compare(a, b) < 0, "%v should sort before %v", values[i-1], values[i])
+		} else {
+			assert.Equal(t, a, b, "%v should encode the same as %v", values[i-1], values[i])
+		}
+	}
+}
+
+func TestEncode_Ints_PreserveOrder(t *testing.T) {
+	values := []int64{math.MinInt64, -1 << 40, -256, -1, 0, 1, 255, 256, 1 << 40, math.MaxInt64}
+	for i := 0; i < 200; i++ {
+		values = append(values, rand.Int63n(1<<20)-1<<19, rand.Int63()-rand.Int63())
+	}
+
+	//act & assert
+	assertOrderPreserved(t, values, func(a, b int64) bool { return a < b })
+}
+
+func TestEncode_IntsAndUints_ShareOrder(t *testing.T) {
+
+	//act
+	negative, _ := Encode(int8(-1))
+	small, _ := Encode(uint8(7))
+	same, _ := Encode(int64(7))
+	big, _ := Encode(uint64(math.MaxUint64))
+
+	//assert
+	assert.True(t, bytes.Compare(negative, small) < 0)
+	assert.Equal(t, small, same)
+	assert.True(t, bytes.Compare(same, big) < 0)
+}
+
+func TestEncode_Floats_PreserveOrder(t *testing.T) {
+	values := []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 0.5, 1, math.MaxFloat64, math.Inf(1)}
+	for i := 0; i < 200; i++ {
+		values = append(values, rand.NormFloat64()*1e6, rand.Float64()-0.5)
+	}
+
+	//act & assert
+	assertOrderPreserved(t, values, func(a, b float64) bool { return a < b })
+}
+
+func TestEncode_NaN_Canonical(t *testing.T) {
+	nans := []float64{math.NaN(), -math.NaN(), math.Float64frombits(0x7ff0000000000001), math.Float64frombits(0xfff8000000000123)}
+	inf := AppendFloat(nil, math.Inf(1))
+
+	for _, nan := range nans {
+
+		//act
+		key := AppendFloat(nil, nan)
+
+		//assert
+		assert.Equal(t, AppendFloat(nil, math.NaN()), key, "%x", math.Float64bits(nan))
+		assert.Equal(t, 1, bytes.Compare(key, inf), "NaN should sort after +Inf")
+		tuple, err := Decode(key)
+		require.NoError(t, err)
+		assert.True(t, math.IsNaN(tuple[0].(float64)))
+	}
+}
+
+func TestEncode_Strings_PreserveOrder(t *testing.T) {
+	values := []string{"", "\x00", "\x00\x00", "\x00\x01", "\x01", "a", "a\x00", "a\x00b", "ab", "b", "\xff", "\xff\xff"}
+	for i := 0; i < 200; i++ {
+		b := make([]byte, rand.Intn(6))
+		for j := range b {
+			b[j] = []byte{0x00, 0x01, 'a', 0xff}[rand.Intn(4)]
+		}
+		values = append(values, string(b))
+	}
+
+	//act & assert
+	assertOrderPreserved(t, values, func(a, b string) bool { return a < b })
+}
+
+func TestEncode_Times_PreserveOrder(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []time.Time{time.Unix(0, 0), time.Unix(-1, 999999999), time.Unix(-1, 0), base}
+	for i := 0; i < 200; i++ {
+		values = append(values, base.Add(time.Duration(rand.Int63()-rand.Int63())))
+	}
+
+	//act & assert
+	assertOrderPreserved(t, values, func(a, b time.Time) bool { return a.Before(b) })
+}
+
+func TestEncode_Tuples_PreserveOrder(t *testing.T) {
+	type pair struct {
+		s string
+		n int64
+	}
+	values := make([]pair, 0)
+	for i := 0; i < 300; i++ {
+		values = append(values, pair{[]string{"", "a", "a\x00", "ab", "b"}[rand.Intn(5)], rand.Int63n(20) - 10})
+	}
+
+	//act & assert
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].s < values[j].s || (values[i].s == values[j].s && values[i].n < values[j].n)
+	})
+	for i := 1; i < len(values); i++ {
+		a, _ := Encode(values[i-1].s, values[i-1].n)
+		b, _ := Encode(values[i].s, values[i].n)
+		assert.True(t, bytes.Compare(a, b) <= 0, "%v should sort before %v", values[i-1], values[i])
+	}
+}
+
+func TestEncode_TypesSortByTag(t *testing.T) {
+	tuple := Tuple{[]byte("z"), "a", int64(-5), int64(5), 1.5, false, true, time.Unix(0, 0)}
+
+	//act & assert
+	for i := 1; i < len(tuple); i++ {
+		a, _ := Encode(tuple[i-1])
+		b, _ := Encode(tuple[i])
+		assert.True(t, bytes.Compare(a, b) < 0, "%T %v should sort before %T %v", tuple[i-1], tuple[i-1], tuple[i], tuple[i])
+	}
+}
+
+func TestDecode_RoundTrips(t *testing.T) {
+	when := time.Date(1969, 7, 20, 20, 17, 40, 123456789, time.UTC)
+	tuple := Tuple{[]byte{0x00, 0xff, 0x00}, "hello\x00world", int64(math.MinInt64), int64(0), int64(42),
+		uint64(math.MaxUint64), -2.5, math.Inf(1), true, false, when, ""}
+
+	//act
+	key, err := tuple.AppendTo(nil)
+	require.NoError(t, err)
+	decoded, err := Decode(key)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, len(tuple), len(decoded))
+	for i := range tuple {
+		assert.Equal(t, tuple[i], decoded[i], "index %d", i)
+	}
+}
+
+func TestDecode_SmallIntegers_DecodeAsInt64(t *testing.T) {
+	key, _ := Encode(uint8(7), int16(-3), float32(0.5))
+
+	//act
+	decoded, err := Decode(key)
+
+	//assert
+	require.NoError(t, err)
+	assert.Equal(t, Tuple{int64(7), int64(-3), 0.5}, decoded)
+}
+
+func TestEncode_UnsupportedType_Errors(t *testing.T) {
+
+	//act
+	_, err := Encode("a", struct{}{})
+
+	//assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1")
+}
+
+func TestDecode_Malformed_Errors(t *testing.T) {
+	cases := map[string]string{
+		"\x02abc":               "keys: unterminated string at offset 0",
+		"\x02a\x00\x06\x00\x00": "truncated integer at offset 3",
+		"\x09\x00":              "truncated time",
+		"\x02a\x00\x7f":         "unknown type tag 0x7f at offset 3",
+		"\x01a":                 "keys: unterminated string at offset 0",
+		"\x01a\x01\x03\x00":     "keys: invalid escape sequence in string at offset 2",
+		"\x02a\x00\x01b\x01":    "keys: invalid escape sequence in string at offset 5",
+	}
+	for key, expected := range cases {
+
+		//act
+		_, err := Decode([]byte(key))
+
+		//assert
+		if assert.Error(t, err, "%q", key) {
+			assert.Contains(t, err.Error(), expected, "%q", key)
+		}
+		if strings.Contains(expected, "escape") {
+			assert.True(t, errors.Is(err, errBadEscape), "%q should wrap errBadEscape", key)
+		}
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+
+	//act & assert
+	assert.Equal(t, []byte{0x01, 0x03}, PrefixEnd([]byte{0x01, 0x02}))
+	assert.Equal(t, []byte{0x02}, PrefixEnd([]byte{0x01, 0xff, 0xff}))
+	assert.Nil(t, PrefixEnd([]byte{0xff, 0xff}))
+	assert.Nil(t, PrefixEnd(nil))
+}
+
+func TestTuplePrefix_ScansTrie(t *testing.T) {
+	trie := critbit.NilTrie()
+	for _, table := range []string{"order", "orders", "orders\x00"} {
+		for id := int64(-3); id <= 3; id++ {
+			key, _ := Encode(table, id, "line")
+			trie, _ = trie.Set(key, id)
+		}
+	}
+	prefix, _ := Encode("orders")
+	expected := []int64{-3, -2, -1, 0, 1, 2, 3}
+
+	//act
+	var byPrefix, byRange []interface{}
+	trie.VisitPrefix(prefix, func(key []byte, val interface{}) bool {
+		tuple, err := Decode(key)
+		require.NoError(t, err)
+		assert.Equal(t, "orders", tuple[0])
+		byPrefix = append(byPrefix, tuple[1])
+		return true
+	})
+	trie.VisitRange(prefix, true, PrefixEnd(prefix), false, func(key []byte, val interface{}) bool {
+		byRange = append(byRange, val)
+		return true
+	})
+
+	//assert
+	require.Equal(t, len(expected), len(byPrefix))
+	for i := range expected {
+		assert.Equal(t, expected[i], byPrefix[i])
+		assert.Equal(t, expected[i], byRange[i])
+	}
+}